@@ -0,0 +1,104 @@
+package morse
+
+import (
+	"fmt"
+)
+
+// maximum number of durations which can be represented in a bitmask
+const maxBitmaskDurations = 10
+
+// runes of durations, for comparing without conversions
+const (
+	ditRune = '•'
+	dahRune = '−'
+)
+
+// table for decoding characters with bitmask indices
+var bitmaskTable [1 << (maxBitmaskDurations + 1)]rune
+
+// builds the bitmask table from the chars map
+//
+// Must be called with `mapsLock` held (or in `init`).
+func buildBitmaskTable() {
+	bitmaskTable = [len(bitmaskTable)]rune{}
+
+	for code, chr := range charsMap {
+		if mask, err := bitmask(code); err == nil {
+			bitmaskTable[mask] = chr
+		}
+	}
+}
+
+// converts given morse code to its bitmask representation.
+//
+// A leading sentinel bit marks the length of the code,
+// and each following bit represents a duration (Dit: 0, Dah: 1).
+// `Space` is represented with the sentinel bit only.
+func bitmask(code Code) (mask uint16, err error) {
+	if code == Space {
+		return 1, nil
+	}
+	if code == None {
+		return 0, fmt.Errorf("no bitmask for an empty code")
+	}
+
+	mask = 1
+	count := 0
+	for _, chr := range code {
+		if count >= maxBitmaskDurations {
+			return 0, fmt.Errorf("code is too long for a bitmask: '%s'", code)
+		}
+
+		switch chr {
+		case ditRune:
+			mask <<= 1
+		case dahRune:
+			mask = mask<<1 | 1
+		default:
+			return 0, fmt.Errorf("not a valid duration: '%c'", chr)
+		}
+		count++
+	}
+
+	return mask, nil
+}
+
+// DecodeFast decodes given morse `codes` to a string, just like `Decode`,
+// but looks up characters from an array indexed with bitmasks instead of a map.
+func DecodeFast(codes []Code) (decoded string, err error) {
+	chars := make([]rune, 0, len(codes))
+
+	mapsLock.RLock()
+	defer mapsLock.RUnlock()
+
+	for _, code := range codes {
+		var mask uint16
+		if mask, err = bitmask(code); err != nil {
+			return "", fmt.Errorf("'%v' are not decodable: %s", codes, err)
+		}
+
+		chr := bitmaskTable[mask]
+		if chr == 0 {
+			return "", fmt.Errorf("'%v' are not decodable: no matching code in the chars map: '%s'", codes, code)
+		}
+
+		chars = append(chars, chr)
+	}
+
+	return string(chars), nil
+}
+
+// DecodeN decodes given `codes` with `Decode` for `n` times, and returns the total number of decoded characters.
+//
+// It is an aid for measuring decoding throughput, along with the benchmarks of `Decode` and `DecodeFast`.
+func DecodeN(codes []Code, n int) (decoded int, err error) {
+	for i := 0; i < n; i++ {
+		var str string
+		if str, err = Decode(codes); err != nil {
+			return decoded, err
+		}
+		decoded += len([]rune(str))
+	}
+
+	return decoded, nil
+}
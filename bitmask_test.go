@@ -0,0 +1,104 @@
+package morse
+
+import (
+	"testing"
+)
+
+func TestDecodeFast(t *testing.T) {
+	escapedPhrase := Escape(testPhrase)
+
+	encoded, err := Encode(escapedPhrase)
+	if err != nil {
+		t.Fatalf("failed to encode: %s", err)
+	}
+
+	// all codes in the map, and the test phrase
+	samples := [][]Code{encoded}
+	for _, code := range codesMap {
+		samples = append(samples, []Code{code})
+	}
+
+	for _, codes := range samples {
+		decoded, err := Decode(codes)
+		if err != nil {
+			t.Errorf("failed to decode: %s", err)
+		}
+
+		decodedFast, err := DecodeFast(codes)
+		if err != nil {
+			t.Errorf("failed to decode fast: %s", err)
+		}
+
+		if decoded != decodedFast {
+			t.Errorf("decoded values do not match: %s / %s", decoded, decodedFast)
+		}
+	}
+
+	// non-decodable codes
+	for _, codes := range [][]Code{
+		{None},
+		{Code("abc")},
+		{CodeFromDurations(Dit, Dit, Dit, Dit, Dit, Dit, Dit, Dit)},
+		{CodeFromDurations(Dit, Dit, Dit, Dit, Dit, Dit, Dit, Dit, Dit, Dit, Dit, Dit)},
+		{S, O, None, S},
+	} {
+		if _, err := Decode(codes); err == nil {
+			t.Errorf("should fail to decode: %v", codes)
+		}
+		if decoded, err := DecodeFast(codes); err == nil {
+			t.Errorf("should fail to decode fast: %v (%s)", codes, decoded)
+		}
+	}
+}
+
+func BenchmarkDecodeFast(b *testing.B) {
+	escapedPhrase := Escape(testPhrase)
+
+	if encoded, err := Encode(escapedPhrase); err == nil {
+		for i := 0; i < b.N; i++ {
+			DecodeFast(encoded)
+		}
+	}
+}
+
+// generates a large message of all codes in the map
+func largeMessage(size int) []Code {
+	codes := make([]Code, 0, size)
+	for len(codes) < size {
+		for _, code := range codesMap {
+			if len(codes) < size {
+				codes = append(codes, code)
+			}
+		}
+	}
+	return codes
+}
+
+func TestDecodeN(t *testing.T) {
+	codes := largeMessage(100)
+
+	if decoded, err := DecodeN(codes, 3); err != nil || decoded != 300 {
+		t.Errorf("expected 300 decoded characters, but got %d (%v)", decoded, err)
+	}
+	if _, err := DecodeN([]Code{None}, 3); err == nil {
+		t.Errorf("should fail to decode an empty code")
+	}
+}
+
+func BenchmarkDecodeLarge(b *testing.B) {
+	codes := largeMessage(10000)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Decode(codes)
+	}
+}
+
+func BenchmarkDecodeFastLarge(b *testing.B) {
+	codes := largeMessage(10000)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		DecodeFast(codes)
+	}
+}
@@ -0,0 +1,261 @@
+package morse
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// DiagnosisType for types of decode errors
+type DiagnosisType string
+
+// Types of decode errors
+const (
+	DiagnosisSimilarCode      DiagnosisType = "similar code"      // a character was copied as another one with a similar code
+	DiagnosisSubstitution     DiagnosisType = "substitution"      // a character was copied as another one
+	DiagnosisMissingWordGap   DiagnosisType = "missing word gap"  // a word gap was not copied
+	DiagnosisExtraWordGap     DiagnosisType = "extra word gap"    // a word gap was copied but not sent
+	DiagnosisMissingCharacter DiagnosisType = "missing character" // a character was not copied
+	DiagnosisExtraCharacter   DiagnosisType = "extra character"   // a character was copied but not sent
+)
+
+// Diagnosis for a decode error
+type Diagnosis struct {
+	Type       DiagnosisType
+	Position   int  // position in the reference text
+	Expected   rune // 0 when nothing was expected
+	Copied     rune // 0 when nothing was copied
+	Suggestion string
+}
+
+// Diagnose aligns `ref` (sent) and `hyp` (copied) texts and returns each difference as a `Diagnosis`.
+//
+// Texts are compared case-insensitively, without the special case of Turkish (so "HI" matches "hi").
+func Diagnose(ref, hyp string) (diagnoses []Diagnosis) {
+	refChars, hypChars := lowerRunes(ref), lowerRunes(hyp)
+
+	diagnoses = []Diagnosis{}
+
+	// word gaps are never aligned with characters
+	for _, e := range align(refChars, hypChars, func(a, b rune) bool {
+		return (a == ' ') == (b == ' ')
+	}) {
+		switch e.op {
+		case opSubstitute:
+			expected, copied := refChars[e.ref], hypChars[e.hyp]
+
+			if similarCodes(expected, copied) {
+				expectedCode, _ := charToCode(expected)
+				copiedCode, _ := charToCode(copied)
+
+				diagnoses = append(diagnoses, Diagnosis{
+					Type:       DiagnosisSimilarCode,
+					Position:   e.ref,
+					Expected:   expected,
+					Copied:     copied,
+					Suggestion: fmt.Sprintf("'%c' (%s) was copied as '%c' (%s): their codes differ in only one duration, so count the dits and dahs carefully", expected, expectedCode, copied, copiedCode),
+				})
+			} else {
+				diagnoses = append(diagnoses, Diagnosis{
+					Type:       DiagnosisSubstitution,
+					Position:   e.ref,
+					Expected:   expected,
+					Copied:     copied,
+					Suggestion: fmt.Sprintf("'%c' was copied as '%c'", expected, copied),
+				})
+			}
+		case opDelete:
+			expected := refChars[e.ref]
+
+			if expected == ' ' {
+				diagnoses = append(diagnoses, Diagnosis{
+					Type:       DiagnosisMissingWordGap,
+					Position:   e.ref,
+					Expected:   expected,
+					Suggestion: "a word gap was missed: listen for the longer silence between words",
+				})
+			} else {
+				diagnoses = append(diagnoses, Diagnosis{
+					Type:       DiagnosisMissingCharacter,
+					Position:   e.ref,
+					Expected:   expected,
+					Suggestion: fmt.Sprintf("'%c' was missed: do not dwell on the previous character", expected),
+				})
+			}
+		case opInsert:
+			copied := hypChars[e.hyp]
+
+			if copied == ' ' {
+				diagnoses = append(diagnoses, Diagnosis{
+					Type:       DiagnosisExtraWordGap,
+					Position:   e.ref,
+					Copied:     copied,
+					Suggestion: "an extra word gap was copied: a gap between characters may have been taken for a gap between words",
+				})
+			} else {
+				diagnoses = append(diagnoses, Diagnosis{
+					Type:       DiagnosisExtraCharacter,
+					Position:   e.ref,
+					Copied:     copied,
+					Suggestion: fmt.Sprintf("'%c' was copied but not sent: it may be noise, or a character split in two", copied),
+				})
+			}
+		}
+	}
+
+	return diagnoses
+}
+
+// AlignOpType for types of alignment operations
+type AlignOpType string
+
+// Types of alignment operations
+const (
+	AlignMatch      AlignOpType = "match"      // codes are the same
+	AlignSubstitute AlignOpType = "substitute" // a code was decoded as another one
+	AlignInsert     AlignOpType = "insert"     // a code was decoded but not in the reference
+	AlignDelete     AlignOpType = "delete"     // a code in the reference was not decoded
+)
+
+// AlignOp for an operation in an alignment of codes
+type AlignOp struct {
+	Type AlignOpType
+	Ref  Code // `None` for insertions
+	Hyp  Code // `None` for deletions
+}
+
+// AlignCodes aligns `ref` (reference) and `hyp` (decoded) codes with the minimum number of edits (Levenshtein distance),
+// and returns the sequence of operations which transforms `ref` to `hyp`.
+func AlignCodes(ref, hyp []Code) (ops []AlignOp) {
+	ops = []AlignOp{}
+
+	for _, e := range align(ref, hyp, nil) {
+		switch e.op {
+		case opMatch:
+			ops = append(ops, AlignOp{Type: AlignMatch, Ref: ref[e.ref], Hyp: hyp[e.hyp]})
+		case opSubstitute:
+			ops = append(ops, AlignOp{Type: AlignSubstitute, Ref: ref[e.ref], Hyp: hyp[e.hyp]})
+		case opInsert:
+			ops = append(ops, AlignOp{Type: AlignInsert, Hyp: hyp[e.hyp]})
+		case opDelete:
+			ops = append(ops, AlignOp{Type: AlignDelete, Ref: ref[e.ref]})
+		}
+	}
+
+	return ops
+}
+
+// lowers each character of given `text` without the special case of Turkish,
+// keeping the number of characters (unlike `strings.ToLower` for 'İ'), so positions are not shifted.
+func lowerRunes(text string) (chars []rune) {
+	chars = []rune(text)
+	for i, chr := range chars {
+		chars[i] = unicode.ToLower(chr)
+	}
+	return chars
+}
+
+// checks if codes of given characters differ in only one duration.
+func similarCodes(a, b rune) bool {
+	codeA, errA := charToCode(a)
+	codeB, errB := charToCode(b)
+	if errA != nil || errB != nil || codeA == Space || codeB == Space {
+		return false
+	}
+
+	edits := 0
+	for _, e := range align([]rune(codeA), []rune(codeB), nil) {
+		if e.op != opMatch {
+			edits++
+		}
+	}
+
+	return edits == 1
+}
+
+// edit operations of an alignment
+type editOp int
+
+const (
+	opMatch editOp = iota
+	opSubstitute
+	opInsert
+	opDelete
+)
+
+// an edit operation with indices of reference and hypothesis
+//
+// `ref` is the position in the reference where the operation happens, and
+// `hyp` is -1 for deletions.
+type edit struct {
+	op  editOp
+	ref int
+	hyp int
+}
+
+// aligns `ref` and `hyp` with the minimum number of edits (Levenshtein distance).
+//
+// When `substitutable` is given, substitutions are only allowed for the pairs it returns true for.
+func align[T comparable](ref, hyp []T, substitutable func(a, b T) bool) (edits []edit) {
+	n, m := len(ref), len(hyp)
+
+	// distances between prefixes
+	dist := make([][]int, n+1)
+	for i := range dist {
+		dist[i] = make([]int, m+1)
+		dist[i][0] = i
+	}
+	for j := 0; j <= m; j++ {
+		dist[0][j] = j
+	}
+
+	canSubstitute := func(i, j int) bool {
+		return ref[i] == hyp[j] || substitutable == nil || substitutable(ref[i], hyp[j])
+	}
+
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			d := min(dist[i-1][j], dist[i][j-1]) + 1
+
+			if canSubstitute(i-1, j-1) {
+				cost := 0
+				if ref[i-1] != hyp[j-1] {
+					cost = 1
+				}
+				d = min(d, dist[i-1][j-1]+cost)
+			}
+
+			dist[i][j] = d
+		}
+	}
+
+	// trace back from the end
+	edits = []edit{}
+	for i, j := n, m; i > 0 || j > 0; {
+		if i > 0 && j > 0 && canSubstitute(i-1, j-1) {
+			if ref[i-1] == hyp[j-1] && dist[i][j] == dist[i-1][j-1] {
+				edits = append(edits, edit{op: opMatch, ref: i - 1, hyp: j - 1})
+				i, j = i-1, j-1
+				continue
+			} else if ref[i-1] != hyp[j-1] && dist[i][j] == dist[i-1][j-1]+1 {
+				edits = append(edits, edit{op: opSubstitute, ref: i - 1, hyp: j - 1})
+				i, j = i-1, j-1
+				continue
+			}
+		}
+
+		if i > 0 && dist[i][j] == dist[i-1][j]+1 {
+			edits = append(edits, edit{op: opDelete, ref: i - 1, hyp: -1})
+			i--
+		} else {
+			edits = append(edits, edit{op: opInsert, ref: i, hyp: j - 1})
+			j--
+		}
+	}
+
+	// reverse to the original order
+	for l, r := 0, len(edits)-1; l < r; l, r = l+1, r-1 {
+		edits[l], edits[r] = edits[r], edits[l]
+	}
+
+	return edits
+}
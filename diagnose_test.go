@@ -0,0 +1,86 @@
+package morse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiagnose(t *testing.T) {
+	// 'o' copied as 'k', word gap missed, and an extra 'e' copied
+	diagnoses := Diagnose("SOS help me", "sks helpmee")
+
+	expected := []DiagnosisType{
+		DiagnosisSimilarCode,
+		DiagnosisMissingWordGap,
+		DiagnosisExtraCharacter,
+	}
+
+	if len(diagnoses) != len(expected) {
+		t.Fatalf("expected %d diagnoses, but got %d: %+v", len(expected), len(diagnoses), diagnoses)
+	}
+	for i, d := range diagnoses {
+		if d.Type != expected[i] {
+			t.Errorf("expected diagnosis type '%s', but got '%s'", expected[i], d.Type)
+		}
+		if d.Suggestion == "" {
+			t.Errorf("no suggestion for diagnosis: %+v", d)
+		}
+	}
+	if diagnoses[0].Expected != 'o' || diagnoses[0].Copied != 'k' || diagnoses[0].Position != 1 {
+		t.Errorf("unexpected substitution: %+v", diagnoses[0])
+	}
+
+	// an extra word gap, dissimilar codes, and a missing character
+	diagnoses = Diagnose("tent", "t mn")
+
+	expected = []DiagnosisType{
+		DiagnosisExtraWordGap,
+		DiagnosisSubstitution,
+		DiagnosisMissingCharacter,
+	}
+
+	if len(diagnoses) != len(expected) {
+		t.Fatalf("expected %d diagnoses, but got %d: %+v", len(expected), len(diagnoses), diagnoses)
+	}
+	for i, d := range diagnoses {
+		if d.Type != expected[i] {
+			t.Errorf("expected diagnosis type '%s', but got '%s'", expected[i], d.Type)
+		}
+	}
+
+	// no errors
+	for ref, hyp := range map[string]string{"Testing": "testing", "HI": "hi", "İstanbul": "istanbul"} {
+		if diagnoses := Diagnose(ref, hyp); len(diagnoses) != 0 {
+			t.Errorf("expected no diagnoses for '%s', but got: %+v", ref, diagnoses)
+		}
+	}
+}
+
+func TestAlignCodes(t *testing.T) {
+	// 'o' was decoded as 'm', and an extra 'e' was decoded
+	ref := []Code{S, O, S}
+	hyp := []Code{S, M, S, E}
+
+	expected := []AlignOp{
+		{Type: AlignMatch, Ref: S, Hyp: S},
+		{Type: AlignSubstitute, Ref: O, Hyp: M},
+		{Type: AlignMatch, Ref: S, Hyp: S},
+		{Type: AlignInsert, Hyp: E},
+	}
+	if ops := AlignCodes(ref, hyp); !reflect.DeepEqual(ops, expected) {
+		t.Errorf("expected %v, but got %v", expected, ops)
+	}
+
+	// deletions
+	expected = []AlignOp{
+		{Type: AlignDelete, Ref: S},
+		{Type: AlignDelete, Ref: O},
+	}
+	if ops := AlignCodes([]Code{S, O}, nil); !reflect.DeepEqual(ops, expected) {
+		t.Errorf("expected %v, but got %v", expected, ops)
+	}
+
+	if ops := AlignCodes(nil, nil); len(ops) != 0 {
+		t.Errorf("expected no operations, but got %v", ops)
+	}
+}
@@ -0,0 +1,130 @@
+package morse
+
+import (
+	"context"
+	"fmt"
+)
+
+// Encoder encodes, decodes, and plays morse codes with its own settings.
+//
+// Its settings cannot be changed after it is created, so it is safe for concurrent use,
+// and encoders with different settings can be used at the same time.
+type Encoder struct {
+	opts  BeepOptions
+	table *CodeTable // nil for the package's codes (including the registered ones)
+}
+
+// Option for configuring an `Encoder`
+type Option func(e *Encoder) error
+
+// WithWPM sets the speed (in words per minute) of an `Encoder`.
+func WithWPM(wpm int) Option {
+	return func(e *Encoder) error {
+		e.opts.WPM = wpm
+		return nil
+	}
+}
+
+// WithHz sets the frequency of tones of an `Encoder`.
+func WithHz(hz int) Option {
+	return func(e *Encoder) error {
+		e.opts.Hz = hz
+		return nil
+	}
+}
+
+// WithSampleRate sets the sample rate of sounds of an `Encoder`.
+func WithSampleRate(sampleRate int) Option {
+	return func(e *Encoder) error {
+		e.opts.SampleRate = sampleRate
+		return nil
+	}
+}
+
+// WithCodeTable sets the characters and their codes of an `Encoder`, instead of the package's ones.
+func WithCodeTable(codes map[rune]Code) Option {
+	return func(e *Encoder) (err error) {
+		e.table, err = NewCodeTable(codes)
+		return err
+	}
+}
+
+// default encoder for the package-level functions
+var defaultEncoder = &Encoder{opts: DefaultBeepOptions()}
+
+// NewEncoder creates a new `Encoder` with the default options (see `DefaultBeepOptions`) overridden by given `opts`.
+//
+// Will return an error when any of the options is not valid.
+func NewEncoder(opts ...Option) (encoder *Encoder, err error) {
+	encoder = &Encoder{opts: DefaultBeepOptions()}
+
+	for _, opt := range opts {
+		if err = opt(encoder); err != nil {
+			return nil, fmt.Errorf("failed to create encoder: %s", err)
+		}
+	}
+	if err = encoder.opts.validate(); err != nil {
+		return nil, fmt.Errorf("failed to create encoder: %s", err)
+	}
+
+	return encoder, nil
+}
+
+// Encode encodes morse codes from given `text`.
+//
+// Consecutive whitespaces are encoded as a single `Space`, just like the ones collapsed with `Escape`.
+//
+// Will return an error when given `text` includes non-encodable characters.
+func (e *Encoder) Encode(text string) (codes []Code, err error) {
+	collapsed := collapseSpaces(text)
+
+	if e.table != nil {
+		return e.table.Encode(collapsed)
+	}
+
+	codes = []Code{}
+
+	for _, chr := range lowerText(collapsed) {
+		var code Code
+		if code, err = charToCode(chr); err != nil {
+			return []Code{}, fmt.Errorf("'%s' is not encodable: %s", text, err)
+		}
+
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// Decode decodes given morse `codes` to a string.
+func (e *Encoder) Decode(codes []Code) (decoded string, err error) {
+	if e.table != nil {
+		return e.table.Decode(codes)
+	}
+
+	chars := []rune{}
+
+	for _, code := range codes {
+		var chr rune
+		if chr, err = codeToChar(code); err != nil {
+			return "", fmt.Errorf("'%v' are not decodable: %s", codes, err)
+		}
+
+		chars = append(chars, chr)
+	}
+
+	return string(chars), nil
+}
+
+// Beep plays sounds for given `codes` synchronously.
+func (e *Encoder) Beep(codes []Code) (err error) {
+	return beepContext(context.Background(), codes, e.opts)
+}
+
+// Timeline returns the tones and silences of given `codes` in order (see `Timeline`).
+//
+// Returns nil when `codes` include invalid ones.
+func (e *Encoder) Timeline(codes []Code) (signals []Signal) {
+	signals, _ = e.opts.schedule(codes, nil)
+	return signals
+}
@@ -0,0 +1,100 @@
+package morse
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestNewEncoder(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithWPM(0)},
+		{WithHz(-1)},
+		{WithSampleRate(-1)},
+		{WithCodeTable(map[rune]Code{'a': A, 'b': A})},
+	} {
+		if _, err := NewEncoder(opts...); err == nil {
+			t.Errorf("should fail to create an encoder with invalid options")
+		}
+	}
+}
+
+func TestEncoderTimelines(t *testing.T) {
+	slow, err := NewEncoder(WithWPM(10))
+	if err != nil {
+		t.Fatalf("failed to create encoder: %s", err)
+	}
+	fast, err := NewEncoder(WithWPM(20), WithHz(600), WithSampleRate(8000))
+	if err != nil {
+		t.Fatalf("failed to create encoder: %s", err)
+	}
+
+	codes, _ := Encode("paris")
+
+	// used from different goroutines at the same time
+	var wg sync.WaitGroup
+	var slowTimeline, fastTimeline []Signal
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		slowTimeline = slow.Timeline(codes)
+	}()
+	go func() {
+		defer wg.Done()
+		fastTimeline = fast.Timeline(codes)
+	}()
+	wg.Wait()
+
+	if !reflect.DeepEqual(slowTimeline, Timeline(codes, 10)) {
+		t.Errorf("unexpected timeline at 10 WPM: %v", slowTimeline)
+	}
+	if !reflect.DeepEqual(fastTimeline, Timeline(codes, 20)) {
+		t.Errorf("unexpected timeline at 20 WPM: %v", fastTimeline)
+	}
+	if len(slowTimeline) != len(fastTimeline) {
+		t.Fatalf("timelines should have the same signals: %d / %d", len(slowTimeline), len(fastTimeline))
+	}
+	for i := range slowTimeline {
+		if slowTimeline[i].Duration != fastTimeline[i].Duration*2 {
+			t.Errorf("signals at 10 WPM should be twice as long as the ones at 20 WPM: %s / %s", slowTimeline[i].Duration, fastTimeline[i].Duration)
+			break
+		}
+	}
+
+	if signals := slow.Timeline([]Code{None}); signals != nil {
+		t.Errorf("expected nil for invalid codes, but got %v", signals)
+	}
+}
+
+func TestEncoderCodeTable(t *testing.T) {
+	// a table with '&' for "es"
+	codes := DefaultCodeTable().Codes()
+	codes['&'] = Ampersand
+
+	encoder, err := NewEncoder(WithCodeTable(codes))
+	if err != nil {
+		t.Fatalf("failed to create encoder: %s", err)
+	}
+
+	if encoded, err := encoder.Encode("a&b"); err != nil {
+		t.Errorf("failed to encode: %s", err)
+	} else if !reflect.DeepEqual(encoded, []Code{A, Ampersand, B}) {
+		t.Errorf("unexpected codes: %v", encoded)
+	} else if decoded, err := encoder.Decode(encoded); err != nil || decoded != "a&b" {
+		t.Errorf("expected 'a&b', but got '%s' (%v)", decoded, err)
+	}
+
+	// settings of the package are not affected
+	if _, err := Encode("a&b"); err == nil {
+		t.Errorf("'&' should not be encodable with the package's codes")
+	}
+
+	// the package's codes by default
+	encoder, _ = NewEncoder()
+	if encoded, err := encoder.Encode("sos"); err != nil || !reflect.DeepEqual(encoded, []Code{S, O, S}) {
+		t.Errorf("unexpected codes: %v (%v)", encoded, err)
+	}
+	if _, err := encoder.Decode([]Code{Ampersand}); err == nil {
+		t.Errorf("'%s' should not be decodable with the package's codes", Ampersand)
+	}
+}
@@ -0,0 +1,390 @@
+package morse
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keywords of timing events
+const (
+	eventOn  = "ON"
+	eventOff = "OFF"
+)
+
+// thresholds (in units) for classifying durations of keying, midway between the standard ones for tolerating jitters
+const (
+	thresholdDah     = 2 // tones shorter than this are dits
+	thresholdCharGap = 2 // silences shorter than this are gaps in characters
+	thresholdWordGap = 5 // silences shorter than this are gaps between characters
+)
+
+// KeyEvent is a press (key down) or a release (key up) of a key, with its duration
+type KeyEvent struct {
+	Down     bool
+	Duration time.Duration
+}
+
+// ReadEvents reads newline-delimited timing events ("ON <ms>" for tones and "OFF <ms>" for silences)
+// from `r`, and converts them to codes.
+//
+// The unit is estimated from durations of both tones and silences, then tones shorter than 2 units are classified
+// as dits (others as dahs), and silences shorter than 2 units as gaps in characters, shorter than 5 units as gaps
+// between characters, and others as gaps between words.
+//
+// Will return an error when the unit cannot be estimated without ambiguity, eg. a single tone ("e" or "t"),
+// or tones of the same length with gaps as long as them ("i" or "tt").
+func ReadEvents(r io.Reader) (codes []Code, err error) {
+	events := []KeyEvent{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed event at line %d: '%s'", line, scanner.Text())
+		}
+
+		var down bool
+		switch strings.ToUpper(fields[0]) {
+		case eventOn:
+			down = true
+		case eventOff:
+			down = false
+		default:
+			return nil, fmt.Errorf("unknown event at line %d: '%s'", line, fields[0])
+		}
+
+		var ms float64
+		if ms, err = strconv.ParseFloat(fields[1], 64); err != nil || ms <= 0 {
+			return nil, fmt.Errorf("invalid duration at line %d: '%s'", line, fields[1])
+		}
+
+		events = append(events, KeyEvent{Down: down, Duration: time.Duration(ms * float64(time.Millisecond))})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read events: %s", err)
+	}
+
+	// from the first tone to the last one
+	var joined []KeyEvent
+	if joined, err = joinEvents(events); err != nil {
+		return nil, err
+	}
+	if len(joined) == 0 {
+		return []Code{}, nil
+	}
+
+	var unit time.Duration
+	if unit, err = unitOfEvents(joined); err != nil {
+		return nil, fmt.Errorf("failed to estimate the unit of events: %s", err)
+	}
+
+	return KeyTimingsToCodes(joined, unit)
+}
+
+// WriteEvents writes newline-delimited timing events ("ON <ms>" for tones and "OFF <ms>" for silences)
+// of given `codes` to `w`, timed to their playback with `opts`, for driving external devices.
+//
+// Written events can be read back with `ReadEvents`.
+// Will return an error when `codes` or `opts` are not valid.
+func WriteEvents(w io.Writer, codes []Code, opts BeepOptions) (err error) {
+	if err = opts.validate(); err != nil {
+		return err
+	}
+
+	var signals []Signal
+	if signals, err = opts.schedule(codes, nil); err != nil {
+		return err
+	}
+
+	for _, signal := range signals {
+		event := eventOff
+		if signal.On {
+			event = eventOn
+		}
+
+		ms := strconv.FormatFloat(float64(signal.Duration)/float64(time.Millisecond), 'f', -1, 64)
+		if _, err = fmt.Fprintf(w, "%s %s\n", event, ms); err != nil {
+			return fmt.Errorf("failed to write events: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// KeyTimingsToCodes converts given key `events` to codes, with given duration of a `unit`.
+//
+// Key downs shorter than 2 units are classified as dits (others as dahs),
+// and key ups shorter than 2 units as gaps in characters, shorter than 5 units as gaps between characters,
+// and others as gaps between words. Consecutive events of the same state are joined,
+// and leading and trailing key ups are ignored.
+//
+// Will return an error when `unit` or any of the durations is not positive.
+func KeyTimingsToCodes(events []KeyEvent, unit time.Duration) (codes []Code, err error) {
+	var keyer *Keyer
+	if keyer, err = NewKeyer(unit, nil); err != nil {
+		return nil, err
+	}
+
+	for i, e := range events {
+		if err = keyer.Key(e); err != nil {
+			return nil, fmt.Errorf("invalid event %d: %s", i, err)
+		}
+	}
+
+	return keyer.Flush(), nil
+}
+
+// DecodeBeam decodes given key `events` with a duration of a `unit`, keeping the `width` most likely hypotheses
+// of classifications of the tones and silences, instead of classifying each of them with thresholds as `KeyTimingsToCodes` does.
+//
+// Each classification costs the log ratio of its duration to the standard one (1 or 3 units for tones,
+// and 1, 3, or 7 units for silences), and hypotheses with characters not in the codes map are discarded,
+// so a sloppy tone or silence is resolved by the characters around it. Leading and trailing key ups are ignored.
+//
+// Will return an error when `unit`, `width`, or any of the durations is not positive,
+// or no hypothesis is made of known characters only.
+func DecodeBeam(events []KeyEvent, unit time.Duration, width int) (decoded string, err error) {
+	if unit <= 0 {
+		return "", fmt.Errorf("unit should be positive: %s", unit)
+	}
+	if width <= 0 {
+		return "", fmt.Errorf("width of beam should be positive: %d", width)
+	}
+
+	var joined []KeyEvent
+	if joined, err = joinEvents(events); err != nil {
+		return "", err
+	}
+
+	// prefixes of codes of known characters, which are true for complete codes
+	mapsLock.RLock()
+	prefixes := map[Code]bool{}
+	for code := range charsMap {
+		for i := range code {
+			if _, exists := prefixes[code[:i]]; !exists {
+				prefixes[code[:i]] = false
+			}
+		}
+		prefixes[code] = true
+	}
+	mapsLock.RUnlock()
+
+	type hypothesis struct {
+		codes   []Code
+		current Code // durations of the current character
+		cost    float64
+	}
+	cost := func(d time.Duration, units int) float64 {
+		return math.Abs(math.Log(float64(d) / float64(unit*time.Duration(units))))
+	}
+
+	beam := []hypothesis{{codes: []Code{}}}
+	for _, e := range joined {
+		next := []hypothesis{}
+		for _, h := range beam {
+			if e.Down {
+				for _, d := range []struct {
+					duration Duration
+					units    int
+				}{{Dit, unitsDit}, {Dah, unitsDah}} {
+					if current := h.current + Code(d.duration); hasPrefix(prefixes, current) {
+						next = append(next, hypothesis{h.codes, current, h.cost + cost(e.Duration, d.units)})
+					}
+				}
+				continue
+			}
+
+			// gap in the character
+			next = append(next, hypothesis{h.codes, h.current, h.cost + cost(e.Duration, unitsIntraGap)})
+
+			// gaps between characters and words, only after a known character
+			if prefixes[h.current] {
+				completed := append(slices.Clone(h.codes), h.current)
+				next = append(next, hypothesis{completed, None, h.cost + cost(e.Duration, unitsCharGap)})
+				next = append(next, hypothesis{append(slices.Clone(completed), Space), None, h.cost + cost(e.Duration, unitsWordGap)})
+			}
+		}
+
+		slices.SortStableFunc(next, func(a, b hypothesis) int {
+			return cmp.Compare(a.cost, b.cost)
+		})
+		beam = next[:min(len(next), width)]
+	}
+
+	// the best one which ends with a known character (or nothing, for no events)
+	for _, h := range beam {
+		if h.current == None {
+			return Decode(h.codes)
+		}
+		if prefixes[h.current] {
+			return Decode(append(h.codes, h.current))
+		}
+	}
+
+	return "", fmt.Errorf("no hypothesis is made of known characters")
+}
+
+// returns whether given `code` is a prefix of (or is) a known code in `prefixes` (see `DecodeBeam`)
+func hasPrefix(prefixes map[Code]bool, code Code) bool {
+	_, exists := prefixes[code]
+	return exists
+}
+
+// DetectWPM infers the speed of given key `events` by splitting durations of key downs into two clusters (dits and dahs),
+// and returns it in WPM along with the estimated duration of a unit (which can be passed to `KeyTimingsToCodes`).
+//
+// Will return an error when there are not enough key downs for distinguishing dits from dahs.
+func DetectWPM(events []KeyEvent) (wpm int, unit time.Duration, err error) {
+	var joined []KeyEvent
+	if joined, err = joinEvents(events); err != nil {
+		return 0, 0, err
+	}
+
+	if unit, err = fitDitsAndDahs(durationsOf(joined, true)); err != nil {
+		return 0, 0, err
+	}
+	wpm = int(math.Round(float64(time.Minute) / (50 * float64(unit))))
+
+	return wpm, unit, nil
+}
+
+// splits given durations of key downs into two clusters (dits and dahs), and returns the duration of a unit
+// weighted by the number of units in each element (see `DetectWPM`).
+//
+// Will return an error when there are not enough key downs, or the clusters are too close to be dits and dahs.
+func fitDitsAndDahs(downs []time.Duration) (unit time.Duration, err error) {
+	if len(downs) < 2 {
+		return 0, fmt.Errorf("not enough key downs for detecting speed: %d", len(downs))
+	}
+	downs = slices.Clone(downs)
+	slices.Sort(downs)
+
+	// split at the point which minimizes the sum of squared errors in both clusters
+	var sum, sumSquares float64
+	sums, sumsSquares := make([]float64, len(downs)+1), make([]float64, len(downs)+1)
+	for i, d := range downs {
+		sum += float64(d)
+		sumSquares += float64(d) * float64(d)
+		sums[i+1], sumsSquares[i+1] = sum, sumSquares
+	}
+	sse := func(from, to int) float64 {
+		s, n := sums[to]-sums[from], float64(to-from)
+		return sumsSquares[to] - sumsSquares[from] - s*s/n
+	}
+	split, best := 0, math.Inf(1)
+	for i := 1; i < len(downs); i++ {
+		if e := sse(0, i) + sse(i, len(downs)); e < best {
+			split, best = i, e
+		}
+	}
+
+	dits, dahs := downs[:split], downs[split:]
+	ditMean := sums[split] / float64(len(dits))
+	dahMean := (sum - sums[split]) / float64(len(dahs))
+	if dahMean < ditMean*thresholdDah {
+		return 0, fmt.Errorf("cannot distinguish dits from dahs: %s and %s in average", time.Duration(ditMean), time.Duration(dahMean))
+	}
+
+	// weighted by the number of units in each element
+	return time.Duration(math.Round(sum / float64(len(dits)*unitsDit+len(dahs)*unitsDah))), nil
+}
+
+// minimum difference of misfits of gaps for telling whether tones of the same length are dits or dahs (see `unitOfEvents`)
+const ambiguityMargin = 0.1
+
+// estimates the duration of a unit of given joined key `events` (see `joinEvents`) from durations of both key downs and ups.
+//
+// When key downs are of two lengths, they are fitted to dits and dahs (see `fitDitsAndDahs`).
+// Otherwise they are all dits or all dahs, and the one with which key ups fit better to the standard gaps
+// (1, 3, and 7 units, relative to each) is taken.
+//
+// Will return an error when both fit almost equally, eg. with a single key down ("e" or "t"),
+// or with key ups as long as key downs ("i" or "tt").
+func unitOfEvents(events []KeyEvent) (unit time.Duration, err error) {
+	downs, ups := durationsOf(events, true), durationsOf(events, false)
+	if len(downs) == 0 {
+		return 0, fmt.Errorf("no key downs for estimating the unit")
+	}
+	if unit, err = fitDitsAndDahs(downs); err == nil {
+		return unit, nil
+	}
+
+	// key downs of the same length
+	var sum time.Duration
+	for _, down := range downs {
+		sum += down
+	}
+	length := float64(sum) / float64(len(downs))
+
+	// sum of relative errors of key ups from the nearest standard gaps, with given unit
+	misfit := func(unit float64) (sum float64) {
+		for _, up := range ups {
+			units := float64(up) / unit
+
+			best := math.Inf(1)
+			for _, standard := range []float64{unitsIntraGap, unitsCharGap, unitsWordGap} {
+				best = math.Min(best, math.Abs(units-standard)/standard)
+			}
+			sum += best
+		}
+		return sum
+	}
+
+	asDits, asDahs := misfit(length/unitsDit), misfit(length/unitsDah)
+	if math.Abs(asDits-asDahs) < ambiguityMargin {
+		return 0, fmt.Errorf("cannot tell whether key downs of %s are dits or dahs", time.Duration(length))
+	}
+	if asDits < asDahs {
+		return time.Duration(math.Round(length / unitsDit)), nil
+	}
+	return time.Duration(math.Round(length / unitsDah)), nil
+}
+
+// returns durations of key downs (or key ups) in given `events`
+func durationsOf(events []KeyEvent, down bool) (durations []time.Duration) {
+	durations = []time.Duration{}
+	for _, e := range events {
+		if e.Down == down {
+			durations = append(durations, e.Duration)
+		}
+	}
+	return durations
+}
+
+// joins consecutive events of the same state, and trims leading and trailing key ups.
+//
+// Will return an error when any of the durations is not positive.
+func joinEvents(events []KeyEvent) (joined []KeyEvent, err error) {
+	joined = []KeyEvent{}
+	for i, e := range events {
+		if e.Duration <= 0 {
+			return nil, fmt.Errorf("duration of event %d should be positive: %s", i, e.Duration)
+		}
+
+		if len(joined) > 0 && joined[len(joined)-1].Down == e.Down {
+			joined[len(joined)-1].Duration += e.Duration
+		} else {
+			joined = append(joined, e)
+		}
+	}
+
+	// trim leading and trailing key ups
+	for len(joined) > 0 && !joined[0].Down {
+		joined = joined[1:]
+	}
+	for len(joined) > 0 && !joined[len(joined)-1].Down {
+		joined = joined[:len(joined)-1]
+	}
+
+	return joined, nil
+}
@@ -0,0 +1,265 @@
+package morse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadEvents(t *testing.T) {
+	// "sos e" with some jitter, and surrounding silences
+	events := `
+OFF 500
+ON 118
+OFF 121
+ON 122
+OFF 119
+ON 120
+OFF 362
+ON 355
+OFF 120
+ON 361
+OFF 118
+ON 358
+OFF 359
+ON 121
+OFF 124
+ON 117
+off 120
+on 120
+OFF 845
+ON 120
+OFF 300
+`
+
+	codes, err := ReadEvents(strings.NewReader(events))
+	if err != nil {
+		t.Fatalf("failed to read events: %s", err)
+	}
+	if expected := []Code{S, O, S, Space, E}; !reflect.DeepEqual(codes, expected) {
+		t.Errorf("expected codes %v, but got %v", expected, codes)
+	}
+
+	// no tones
+	if codes, err := ReadEvents(strings.NewReader("OFF 100\n")); err != nil || len(codes) != 0 {
+		t.Errorf("expected no codes, but got %v (%v)", codes, err)
+	}
+
+	// malformed events
+	for _, malformed := range []string{
+		"ON\n",
+		"BLINK 100\n",
+		"ON -100\n",
+		"ON 100 OFF 100\n",
+	} {
+		if _, err := ReadEvents(strings.NewReader(malformed)); err == nil {
+			t.Errorf("should fail to read malformed events: %q", malformed)
+		}
+	}
+}
+
+func TestWriteEvents(t *testing.T) {
+	codes, _ := Encode("e t")
+
+	// 10 WPM: a unit of 120ms
+	var buf strings.Builder
+	if err := WriteEvents(&buf, codes, DefaultBeepOptions()); err != nil {
+		t.Fatalf("failed to write events: %s", err)
+	}
+	if expected := "ON 120\nOFF 840\nON 360\n"; buf.String() != expected {
+		t.Errorf("expected events '%s', but got '%s'", expected, buf.String())
+	}
+
+	// round trip
+	codes, _ = Encode("sos cq de k")
+	buf.Reset()
+	if err := WriteEvents(&buf, codes, DefaultBeepOptions()); err != nil {
+		t.Fatalf("failed to write events: %s", err)
+	}
+	if read, err := ReadEvents(strings.NewReader(buf.String())); err != nil {
+		t.Errorf("failed to read events: %s", err)
+	} else if !reflect.DeepEqual(read, codes) {
+		t.Errorf("expected %v read back, but got %v", codes, read)
+	}
+
+	// round trips of tones of the same length, told apart by gaps
+	for _, text := range []string{"t t", "tom", "m", "hi", "eee", "e e"} {
+		codes, _ := Encode(text)
+		buf.Reset()
+		_ = WriteEvents(&buf, codes, DefaultBeepOptions())
+		if read, err := ReadEvents(strings.NewReader(buf.String())); err != nil {
+			t.Errorf("failed to read events of '%s': %s", text, err)
+		} else if decoded, _ := Decode(read); decoded != text {
+			t.Errorf("expected '%s' read back, but got '%s'", text, decoded)
+		}
+	}
+
+	// ambiguous ones are not guessed ("t" or "e", "tt" or "i")
+	for _, text := range []string{"t", "tt", "e", "i"} {
+		codes, _ := Encode(text)
+		buf.Reset()
+		_ = WriteEvents(&buf, codes, DefaultBeepOptions())
+		if read, err := ReadEvents(strings.NewReader(buf.String())); err == nil {
+			t.Errorf("should fail to read ambiguous events of '%s', but got %v", text, read)
+		}
+	}
+
+	if err := WriteEvents(&buf, []Code{Code("abc")}, DefaultBeepOptions()); err == nil {
+		t.Errorf("should fail with invalid codes")
+	}
+}
+
+func TestKeyTimingsToCodes(t *testing.T) {
+	ms := time.Millisecond
+
+	// "sos" keyed by hand at about 80ms per unit
+	events := []KeyEvent{
+		{Down: false, Duration: 500 * ms}, // before keying
+
+		{Down: true, Duration: 75 * ms}, {Down: false, Duration: 90 * ms},
+		{Down: true, Duration: 88 * ms}, {Down: false, Duration: 70 * ms},
+		{Down: true, Duration: 95 * ms}, {Down: false, Duration: 230 * ms},
+
+		{Down: true, Duration: 250 * ms}, {Down: false, Duration: 85 * ms},
+		{Down: true, Duration: 220 * ms}, {Down: false, Duration: 60 * ms}, {Down: false, Duration: 20 * ms}, // bounced
+		{Down: true, Duration: 260 * ms}, {Down: false, Duration: 270 * ms},
+
+		{Down: true, Duration: 70 * ms}, {Down: false, Duration: 82 * ms},
+		{Down: true, Duration: 85 * ms}, {Down: false, Duration: 79 * ms},
+		{Down: true, Duration: 90 * ms}, {Down: false, Duration: 600 * ms},
+
+		{Down: true, Duration: 245 * ms}, // 't' after a word gap
+
+		{Down: false, Duration: 1000 * ms}, // after keying
+	}
+
+	codes, err := KeyTimingsToCodes(events, 80*ms)
+	if err != nil {
+		t.Fatalf("failed to convert key timings: %s", err)
+	}
+	if expected := []Code{S, O, S, Space, T}; !reflect.DeepEqual(codes, expected) {
+		t.Errorf("expected %v, but got %v", expected, codes)
+	}
+
+	if codes, err := KeyTimingsToCodes(nil, 80*ms); err != nil || len(codes) != 0 {
+		t.Errorf("expected no codes, but got %v (%v)", codes, err)
+	}
+
+	// errors
+	if _, err := KeyTimingsToCodes(events, 0); err == nil {
+		t.Errorf("should fail with an invalid unit")
+	}
+	if _, err := KeyTimingsToCodes([]KeyEvent{{Down: true, Duration: -ms}}, 80*ms); err == nil {
+		t.Errorf("should fail with an invalid duration")
+	}
+}
+
+func TestDecodeBeam(t *testing.T) {
+	ms := time.Millisecond
+
+	// "73 k" at 100ms per unit, with the last dit of '7' stretched like a dah
+	events := []KeyEvent{
+		{Down: true, Duration: 300 * ms}, {Down: false, Duration: 100 * ms},
+		{Down: true, Duration: 300 * ms}, {Down: false, Duration: 100 * ms},
+		{Down: true, Duration: 100 * ms}, {Down: false, Duration: 100 * ms},
+		{Down: true, Duration: 100 * ms}, {Down: false, Duration: 100 * ms},
+		{Down: true, Duration: 210 * ms}, {Down: false, Duration: 300 * ms}, // sloppy
+
+		{Down: true, Duration: 100 * ms}, {Down: false, Duration: 100 * ms},
+		{Down: true, Duration: 100 * ms}, {Down: false, Duration: 100 * ms},
+		{Down: true, Duration: 100 * ms}, {Down: false, Duration: 100 * ms},
+		{Down: true, Duration: 300 * ms}, {Down: false, Duration: 100 * ms},
+		{Down: true, Duration: 300 * ms}, {Down: false, Duration: 700 * ms},
+
+		{Down: true, Duration: 300 * ms}, {Down: false, Duration: 100 * ms},
+		{Down: true, Duration: 100 * ms}, {Down: false, Duration: 100 * ms},
+		{Down: true, Duration: 300 * ms},
+	}
+
+	// greedy classification fails with an unknown code
+	if codes, err := KeyTimingsToCodes(events, 100*ms); err != nil {
+		t.Fatalf("failed to convert key timings: %s", err)
+	} else if decoded, err := Decode(codes); err == nil {
+		t.Errorf("expected greedy decoding to fail, but got '%s'", decoded)
+	}
+
+	// beam search recovers the message
+	decoded, err := DecodeBeam(events, 100*ms, 8)
+	if err != nil {
+		t.Fatalf("failed to decode with beam search: %s", err)
+	}
+	if decoded != "73 k" {
+		t.Errorf("expected '73 k', but got '%s'", decoded)
+	}
+
+	if decoded, err := DecodeBeam(nil, 100*ms, 8); err != nil || decoded != "" {
+		t.Errorf("expected nothing decoded, but got '%s' (%v)", decoded, err)
+	}
+
+	// errors
+	if _, err := DecodeBeam(events, 0, 8); err == nil {
+		t.Errorf("should fail with an invalid unit")
+	}
+	if _, err := DecodeBeam(events, 100*ms, 0); err == nil {
+		t.Errorf("should fail with an invalid width")
+	}
+	if _, err := DecodeBeam([]KeyEvent{{Down: true, Duration: -ms}}, 100*ms, 8); err == nil {
+		t.Errorf("should fail with an invalid duration")
+	}
+}
+
+func TestDetectWPM(t *testing.T) {
+	ms := time.Millisecond
+
+	// "paris" at 13 WPM (unit: ~92ms), with jitters
+	events := []KeyEvent{
+		{Down: true, Duration: 88 * ms}, {Down: false, Duration: 95 * ms},
+		{Down: true, Duration: 281 * ms}, {Down: false, Duration: 90 * ms},
+		{Down: true, Duration: 270 * ms}, {Down: false, Duration: 97 * ms},
+		{Down: true, Duration: 96 * ms}, {Down: false, Duration: 283 * ms},
+
+		{Down: true, Duration: 90 * ms}, {Down: false, Duration: 88 * ms},
+		{Down: true, Duration: 285 * ms}, {Down: false, Duration: 270 * ms},
+
+		{Down: true, Duration: 94 * ms}, {Down: false, Duration: 92 * ms},
+		{Down: true, Duration: 272 * ms}, {Down: false, Duration: 96 * ms},
+		{Down: true, Duration: 89 * ms}, {Down: false, Duration: 290 * ms},
+
+		{Down: true, Duration: 97 * ms}, {Down: false, Duration: 85 * ms},
+		{Down: true, Duration: 91 * ms}, {Down: false, Duration: 276 * ms},
+
+		{Down: true, Duration: 86 * ms}, {Down: false, Duration: 94 * ms},
+		{Down: true, Duration: 93 * ms}, {Down: false, Duration: 90 * ms},
+		{Down: true, Duration: 98 * ms},
+	}
+
+	wpm, unit, err := DetectWPM(events)
+	if err != nil {
+		t.Fatalf("failed to detect WPM: %s", err)
+	}
+	if wpm != 13 {
+		t.Errorf("expected 13 WPM, but got %d (unit: %s)", wpm, unit)
+	}
+
+	// decoded with the detected unit
+	codes, err := KeyTimingsToCodes(events, unit)
+	if err != nil {
+		t.Fatalf("failed to convert key timings: %s", err)
+	}
+	if decoded, err := Decode(codes); err != nil || decoded != "paris" {
+		t.Errorf("expected 'paris', but got '%s' (%v)", decoded, err)
+	}
+
+	// errors
+	if _, _, err := DetectWPM([]KeyEvent{{Down: true, Duration: 90 * ms}}); err == nil {
+		t.Errorf("should fail with only one key down")
+	}
+	if _, _, err := DetectWPM([]KeyEvent{
+		{Down: true, Duration: 90 * ms}, {Down: false, Duration: 90 * ms},
+		{Down: true, Duration: 95 * ms}, {Down: false, Duration: 90 * ms},
+		{Down: true, Duration: 88 * ms},
+	}); err == nil {
+		t.Errorf("should fail with only dits")
+	}
+}
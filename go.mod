@@ -2,7 +2,10 @@ module github.com/meinside/morse-go
 
 go 1.21.4
 
-require github.com/faiface/beep v1.1.0
+require (
+	github.com/faiface/beep v1.1.0
+	golang.org/x/text v0.16.0
+)
 
 require (
 	github.com/hajimehoshi/oto v0.7.1 // indirect
@@ -0,0 +1,142 @@
+package morse
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventType for types of keyer events
+type EventType string
+
+// Types of keyer events
+const (
+	EventDitDetected  EventType = "dit detected"  // a key down was classified as a dit
+	EventDahDetected  EventType = "dah detected"  // a key down was classified as a dah
+	EventCharComplete EventType = "char complete" // a gap (or `Flush`) completed a character
+	EventWordComplete EventType = "word complete" // a gap completed a word
+)
+
+// Event of a keyer
+type Event struct {
+	Type EventType
+	Code Code // completed code for `EventCharComplete`, `None` for others
+}
+
+// Keyer is a state machine which converts key events to codes incrementally,
+// in the same way as `KeyTimingsToCodes`.
+//
+// As a key event is classified only when the state of the key changes,
+// each `Event` is sent on the next event of the other state (or on `Flush`).
+type Keyer struct {
+	unit   time.Duration
+	events chan<- Event
+
+	started  bool          // whether any key down was fed
+	down     bool          // state of the current run of key events
+	duration time.Duration // duration of the current run of key events
+
+	durations []Duration // durations of the current character
+	codes     []Code
+}
+
+// NewKeyer creates a new `Keyer` with given duration of a `unit`.
+//
+// When `events` is not nil, each `Event` is sent to it synchronously (so it blocks until received).
+//
+// Will return an error when `unit` is not positive.
+func NewKeyer(unit time.Duration, events chan<- Event) (keyer *Keyer, err error) {
+	if unit <= 0 {
+		return nil, fmt.Errorf("unit should be positive: %s", unit)
+	}
+
+	return &Keyer{
+		unit:      unit,
+		events:    events,
+		durations: []Duration{},
+		codes:     []Code{},
+	}, nil
+}
+
+// Key feeds given key event `e` to the keyer.
+//
+// Consecutive events of the same state are joined, and leading key ups are ignored.
+//
+// Will return an error when the duration of `e` is not positive.
+func (k *Keyer) Key(e KeyEvent) (err error) {
+	if e.Duration <= 0 {
+		return fmt.Errorf("duration of event should be positive: %s", e.Duration)
+	}
+
+	if !k.started {
+		if !e.Down {
+			return nil
+		}
+		k.started, k.down = true, true
+	}
+
+	if e.Down != k.down {
+		k.transition()
+		k.down, k.duration = e.Down, 0
+	}
+	k.duration += e.Duration
+
+	return nil
+}
+
+// Flush completes the current character (ignoring trailing key ups),
+// and returns all the codes converted so far. The keyer is reset for reuse.
+func (k *Keyer) Flush() (codes []Code) {
+	if k.started && k.down {
+		k.transition()
+	}
+	k.completeChar()
+
+	codes = k.codes
+
+	k.started, k.down, k.duration = false, false, 0
+	k.durations, k.codes = []Duration{}, []Code{}
+
+	return codes
+}
+
+// classifies the current run of key events
+func (k *Keyer) transition() {
+	units := float64(k.duration) / float64(k.unit)
+
+	if k.down {
+		if units < thresholdDah {
+			k.durations = append(k.durations, Dit)
+			k.emit(Event{Type: EventDitDetected})
+		} else {
+			k.durations = append(k.durations, Dah)
+			k.emit(Event{Type: EventDahDetected})
+		}
+	} else if units >= thresholdCharGap {
+		k.completeChar()
+
+		if units >= thresholdWordGap {
+			k.codes = append(k.codes, Space)
+			k.emit(Event{Type: EventWordComplete})
+		}
+	}
+}
+
+// completes the current character, if any
+func (k *Keyer) completeChar() {
+	if len(k.durations) == 0 {
+		return
+	}
+
+	code := CodeFromDurations(k.durations...)
+	k.codes = append(k.codes, code)
+	k.durations = []Duration{}
+
+	k.emit(Event{Type: EventCharComplete, Code: code})
+}
+
+// sends given event, if there is a channel for events
+func (k *Keyer) emit(e Event) {
+	if k.events != nil {
+		k.events <- e
+	}
+}
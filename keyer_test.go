@@ -0,0 +1,72 @@
+package morse
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestKeyer(t *testing.T) {
+	ms := time.Millisecond
+
+	events := make(chan Event, 32)
+	keyer, err := NewKeyer(80*ms, events)
+	if err != nil {
+		t.Fatalf("failed to create keyer: %s", err)
+	}
+
+	// "it t", with a leading key up which is ignored
+	for _, e := range []KeyEvent{
+		{Down: false, Duration: 500 * ms},
+		{Down: true, Duration: 75 * ms}, {Down: false, Duration: 85 * ms},
+		{Down: true, Duration: 90 * ms}, {Down: false, Duration: 250 * ms},
+		{Down: true, Duration: 240 * ms}, {Down: false, Duration: 300 * ms}, {Down: false, Duration: 300 * ms}, // joined
+		{Down: true, Duration: 230 * ms},
+	} {
+		if err := keyer.Key(e); err != nil {
+			t.Fatalf("failed to key: %s", err)
+		}
+	}
+
+	// the last dah is not classified until flushed
+	if len(events) != 6 {
+		t.Errorf("expected 6 events before flushing, but got %d", len(events))
+	}
+
+	codes := keyer.Flush()
+	if expected := []Code{I, T, Space, T}; !reflect.DeepEqual(codes, expected) {
+		t.Errorf("expected %v, but got %v", expected, codes)
+	}
+
+	close(events)
+	received := []Event{}
+	for e := range events {
+		received = append(received, e)
+	}
+	expected := []Event{
+		{Type: EventDitDetected},
+		{Type: EventDitDetected},
+		{Type: EventCharComplete, Code: I},
+		{Type: EventDahDetected},
+		{Type: EventCharComplete, Code: T},
+		{Type: EventWordComplete},
+		{Type: EventDahDetected},
+		{Type: EventCharComplete, Code: T},
+	}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("expected %v, but got %v", expected, received)
+	}
+
+	// reset after flushing
+	if codes := keyer.Flush(); len(codes) != 0 {
+		t.Errorf("expected no codes after flushing, but got %v", codes)
+	}
+
+	// errors
+	if _, err := NewKeyer(0, nil); err == nil {
+		t.Errorf("should fail with an invalid unit")
+	}
+	if err := keyer.Key(KeyEvent{Down: true}); err == nil {
+		t.Errorf("should fail with an invalid duration")
+	}
+}
@@ -0,0 +1,124 @@
+package morse
+
+import (
+	"math"
+	"time"
+)
+
+// KeyingReport for timing quality of keying
+type KeyingReport struct {
+	Dits, Dahs int // number of tones
+
+	DitMean, DahMean time.Duration // mean durations of tones
+
+	// coefficients of variation (standard deviation / mean) of tones, 0 for perfectly even ones
+	DitVariation, DahVariation float64
+
+	Ratio       float64 // of the mean dah to the mean dit, 3.0 in the standard timing
+	Consistency float64 // 0.0 ~ 1.0, 1.0 for perfectly even tones
+	Mismatches  int     // number of tones which were not sent as expected
+}
+
+// AnalyzeKeying analyzes timing quality of given on/off durations (starting with a tone) of keying.
+//
+// Tones are classified into dits and dahs with a threshold of 2 units (of the shortest tone),
+// and their variations are reported. Gaps are ignored.
+func AnalyzeKeying(onOff []time.Duration) KeyingReport {
+	return analyzeKeying(tonesFrom(onOff), nil)
+}
+
+// AnalyzeVTest analyzes timing quality of given on/off durations (starting with a tone) of a run of "V"s (•••−),
+// which operators send continuously for testing.
+//
+// Tones are expected to be 3 dits and a dah repeatedly, and the ones which do not fit are counted as `Mismatches`.
+func AnalyzeVTest(onOff []time.Duration) KeyingReport {
+	v := []rune(V)
+
+	return analyzeKeying(tonesFrom(onOff), func(i int) rune {
+		return v[i%len(v)]
+	})
+}
+
+// returns tones from given on/off durations
+func tonesFrom(onOff []time.Duration) (tones []time.Duration) {
+	tones = []time.Duration{}
+	for i := 0; i < len(onOff); i += 2 {
+		tones = append(tones, onOff[i])
+	}
+
+	return tones
+}
+
+// analyzes given `tones` with `expected` durations (`ditRune` or `dahRune`) of them,
+// or classifies them with a threshold when `expected` is nil.
+func analyzeKeying(tones []time.Duration, expected func(i int) rune) (report KeyingReport) {
+	if len(tones) == 0 {
+		return report
+	}
+
+	shortest := tones[0]
+	for _, tone := range tones {
+		shortest = min(shortest, tone)
+	}
+	classify := func(tone time.Duration) rune {
+		if tone >= shortest*2 {
+			return dahRune
+		}
+		return ditRune
+	}
+
+	dits, dahs := []float64{}, []float64{}
+	for i, tone := range tones {
+		element := classify(tone)
+		if expected != nil {
+			if want := expected(i); want != element {
+				report.Mismatches++
+				element = want
+			}
+		}
+
+		if element == dahRune {
+			dahs = append(dahs, float64(tone))
+		} else {
+			dits = append(dits, float64(tone))
+		}
+	}
+
+	ditMean, ditVariation := meanAndVariation(dits)
+	dahMean, dahVariation := meanAndVariation(dahs)
+
+	report.Dits, report.Dahs = len(dits), len(dahs)
+	report.DitMean, report.DahMean = time.Duration(math.Round(ditMean)), time.Duration(math.Round(dahMean))
+	report.DitVariation, report.DahVariation = ditVariation, dahVariation
+	if ditMean > 0 && dahMean > 0 {
+		report.Ratio = dahMean / ditMean
+	}
+
+	// mean variation of the classes of tones, weighted by their counts
+	variation := (ditVariation*float64(len(dits)) + dahVariation*float64(len(dahs))) / float64(len(tones))
+	report.Consistency = max(0, 1-variation)
+
+	return report
+}
+
+// returns the mean and the coefficient of variation of given `values`
+func meanAndVariation(values []float64) (mean, variation float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	if mean > 0 {
+		variation = math.Sqrt(squares/float64(len(values))) / mean
+	}
+
+	return mean, variation
+}
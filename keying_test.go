@@ -0,0 +1,96 @@
+package morse
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// returns on/off durations of a run of "V"s with given jitter (as a fraction of the unit)
+func vRun(count int, unit time.Duration, jitter []float64) (onOff []time.Duration) {
+	n := 0
+	next := func(units int64) time.Duration {
+		d := unit*time.Duration(units) + time.Duration(float64(unit)*jitter[n%len(jitter)])
+		n++
+		return d
+	}
+
+	for i := 0; i < count; i++ {
+		for j, chr := range []rune(V) {
+			if chr == dahRune {
+				onOff = append(onOff, next(unitsDah))
+			} else {
+				onOff = append(onOff, next(unitsDit))
+			}
+
+			if j < len([]rune(V))-1 {
+				onOff = append(onOff, next(unitsIntraGap))
+			} else {
+				onOff = append(onOff, next(unitsCharGap))
+			}
+		}
+	}
+
+	return onOff
+}
+
+func TestAnalyzeVTest(t *testing.T) {
+	unit := 60 * time.Millisecond
+
+	// a clean run
+	report := AnalyzeVTest(vRun(10, unit, []float64{0.02, -0.03, 0.01, 0, -0.01}))
+	if report.Dits != 30 || report.Dahs != 10 {
+		t.Errorf("expected 30 dits and 10 dahs, but got %d and %d", report.Dits, report.Dahs)
+	}
+	if report.Mismatches != 0 {
+		t.Errorf("expected no mismatches, but got %d", report.Mismatches)
+	}
+	if report.Consistency < 0.95 {
+		t.Errorf("expected high consistency, but got %f", report.Consistency)
+	}
+	if math.Abs(report.Ratio-3) > 0.1 {
+		t.Errorf("expected the ratio of 3, but got %f", report.Ratio)
+	}
+	if diff := report.DitMean - unit; diff < -2*time.Millisecond || diff > 2*time.Millisecond {
+		t.Errorf("expected the mean dit of %s, but got %s", unit, report.DitMean)
+	}
+
+	// a sloppy run with a dah sent as a dit
+	sloppy := vRun(10, unit, []float64{0.2, -0.3, 0.1, 0, -0.2})
+	sloppy[6] = unit
+	if report := AnalyzeVTest(sloppy); report.Mismatches != 1 {
+		t.Errorf("expected a mismatch, but got %d", report.Mismatches)
+	} else if report.Consistency >= 0.95 {
+		t.Errorf("expected lower consistency, but got %f", report.Consistency)
+	}
+
+	// without tones
+	if report := AnalyzeVTest(nil); report.Dits != 0 || report.Dahs != 0 || report.Consistency != 0 {
+		t.Errorf("expected an empty report, but got %+v", report)
+	}
+}
+
+func TestAnalyzeKeying(t *testing.T) {
+	unit := 100 * time.Millisecond
+
+	// "sos" with some jitter
+	onOff := []time.Duration{}
+	for i, chr := range []rune(S + O + S) {
+		tone := unit
+		if chr == dahRune {
+			tone = 3 * unit
+		}
+		onOff = append(onOff, tone+time.Duration(i%3-1)*2*time.Millisecond, unit)
+	}
+
+	report := AnalyzeKeying(onOff)
+	if report.Dits != 6 || report.Dahs != 3 {
+		t.Errorf("expected 6 dits and 3 dahs, but got %d and %d", report.Dits, report.Dahs)
+	}
+	if report.Mismatches != 0 {
+		t.Errorf("expected no mismatches without expectations, but got %d", report.Mismatches)
+	}
+	if report.Consistency < 0.95 {
+		t.Errorf("expected high consistency, but got %f", report.Consistency)
+	}
+}
@@ -0,0 +1,102 @@
+package morse
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ASCII symbols of codes in messages
+const (
+	messageDit  = "."
+	messageDah  = "-"
+	messageWord = "/"
+)
+
+// JSON envelope of a message
+type message struct {
+	Text    string          `json:"text"`
+	Codes   []string        `json:"codes"`
+	Timings []messageTiming `json:"timings"`
+	Options BeepOptions     `json:"options"`
+}
+
+// timing of a code in a message, in milliseconds
+type messageTiming struct {
+	Start    float64 `json:"start_ms"`
+	Duration float64 `json:"duration_ms"`
+}
+
+// MarshalMessage encodes given `text` (with prosigns embedded as in `EncodeText`) to a self-describing JSON message,
+// with the text, ASCII codes ('.' and '-' for dits and dahs, "/" for word gaps) of its characters,
+// the start offset and duration of each code (in milliseconds, timed to its playback with `opts` as `StartOffsets`,
+// lasting until its last tone when sent repeatedly), and `opts` (with keys in snake case, eg. "char_wpm").
+//
+// Will return an error when `text` is not encodable or `opts` are not valid.
+func MarshalMessage(text string, opts BeepOptions) (marshalled []byte, err error) {
+	if err = opts.validate(); err != nil {
+		return nil, err
+	}
+
+	var codes []Code
+	if codes, err = EncodeText(text); err != nil {
+		return nil, err
+	}
+
+	var starts, ends []time.Duration
+	if starts, ends, err = opts.spans(codes); err != nil {
+		return nil, err
+	}
+
+	msg := message{
+		Text:    text,
+		Codes:   make([]string, len(codes)),
+		Timings: make([]messageTiming, len(codes)),
+		Options: opts,
+	}
+	replacer := strings.NewReplacer(string(Dit), messageDit, string(Dah), messageDah)
+	for i, code := range codes {
+		msg.Timings[i] = messageTiming{
+			Start:    float64(starts[i]) / float64(time.Millisecond),
+			Duration: float64(ends[i]-starts[i]) / float64(time.Millisecond),
+		}
+
+		if code == Space {
+			msg.Codes[i] = messageWord
+			continue
+		}
+
+		msg.Codes[i] = replacer.Replace(string(code))
+	}
+
+	return json.Marshal(msg)
+}
+
+// UnmarshalMessage decodes given JSON message `data` (see `MarshalMessage`) to its text, codes, and options.
+//
+// Will return an error when `data` is malformed, or its codes or options are not valid.
+func UnmarshalMessage(data []byte) (text string, codes []Code, opts BeepOptions, err error) {
+	var msg message
+	if err = json.Unmarshal(data, &msg); err != nil {
+		return "", nil, BeepOptions{}, fmt.Errorf("failed to unmarshal message: %s", err)
+	}
+
+	if err = msg.Options.validate(); err != nil {
+		return "", nil, BeepOptions{}, fmt.Errorf("invalid options in message: %s", err)
+	}
+
+	codes = make([]Code, len(msg.Codes))
+	for i, ascii := range msg.Codes {
+		if ascii == messageWord {
+			codes[i] = Space
+			continue
+		}
+
+		if codes[i] = normalizeCode(Code(ascii)); !codes[i].Valid() {
+			return "", nil, BeepOptions{}, fmt.Errorf("invalid code at %d in message: '%s'", i, ascii)
+		}
+	}
+
+	return msg.Text, codes, msg.Options, nil
+}
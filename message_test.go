@@ -0,0 +1,101 @@
+package morse
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarshalMessage(t *testing.T) {
+	text := "cq de hl1abc <KN>"
+
+	opts := DefaultBeepOptions()
+	opts.CharWPM, opts.EffectiveWPM = 18, 10
+
+	marshalled, err := MarshalMessage(text, opts)
+	if err != nil {
+		t.Fatalf("failed to marshal message: %s", err)
+	}
+
+	// self-describing envelope
+	var envelope map[string]any
+	if err := json.Unmarshal(marshalled, &envelope); err != nil {
+		t.Fatalf("failed to unmarshal envelope: %s", err)
+	}
+	for _, key := range []string{"text", "codes", "timings", "options"} {
+		if _, exists := envelope[key]; !exists {
+			t.Errorf("expected '%s' in the message: %s", key, marshalled)
+		}
+	}
+	if codes := envelope["codes"].([]any); codes[0] != "-.-." || codes[2] != "/" {
+		t.Errorf("expected ASCII codes, but got %v", codes)
+	}
+	for _, key := range []string{"hz", "wpm", "volume", "sample_rate", "char_wpm", "effective_wpm", "repeat_each"} {
+		if _, exists := envelope["options"].(map[string]any)[key]; !exists {
+			t.Errorf("expected '%s' in the options: %s", key, marshalled)
+		}
+	}
+
+	// round trip
+	decodedText, codes, decodedOpts, err := UnmarshalMessage(marshalled)
+	if err != nil {
+		t.Fatalf("failed to unmarshal message: %s", err)
+	}
+	if decodedText != text {
+		t.Errorf("expected text '%s', but got '%s'", text, decodedText)
+	}
+	if expected, _ := EncodeText(text); !reflect.DeepEqual(codes, expected) {
+		t.Errorf("expected codes %v, but got %v", expected, codes)
+	}
+	if decodedOpts != opts {
+		t.Errorf("expected options %+v, but got %+v", opts, decodedOpts)
+	}
+
+	// timings of codes
+	var msg message
+	_ = json.Unmarshal(marshalled, &msg)
+	for i, offset := range StartOffsets(codes, opts) {
+		if math.Abs(msg.Timings[i].Start-float64(offset)/float64(time.Millisecond)) > 1e-6 {
+			t.Errorf("expected code %d to start at %s, but got %f ms", i, offset, msg.Timings[i].Start)
+		}
+	}
+
+	// timings follow the schedule, with characters sent repeatedly and a preamble
+	opts.RepeatEach, opts.PreambleDits = 2, 3
+	marshalled, _ = MarshalMessage("ab c", opts)
+	msg = message{}
+	_ = json.Unmarshal(marshalled, &msg)
+
+	codes, _ = Encode("ab c")
+	var end time.Duration
+	for _, signal := range TimelineWith(codes, opts) {
+		end += signal.Duration
+	}
+	for i, offset := range StartOffsets(codes, opts) {
+		if math.Abs(msg.Timings[i].Start-float64(offset)/float64(time.Millisecond)) > 1e-6 {
+			t.Errorf("expected code %d to start at %s, but got %f ms", i, offset, msg.Timings[i].Start)
+		}
+	}
+	unit, charGap, _ := opts.timings()
+	if duration := msg.Timings[0].Duration; math.Abs(duration-float64(2*5*unit+charGap)/float64(time.Millisecond)) > 1e-6 { // 'a' (.-) twice
+		t.Errorf("expected 'a' to last while sent twice, but got %f ms", duration)
+	}
+	if last := msg.Timings[len(msg.Timings)-1]; math.Abs(last.Start+last.Duration-float64(end)/float64(time.Millisecond)) > 1e-6 {
+		t.Errorf("expected the last code to end at %s, but got %+v", end, last)
+	}
+
+	if _, err := MarshalMessage("~", opts); err == nil {
+		t.Errorf("should fail with a non-encodable text")
+	}
+	if _, _, _, err := UnmarshalMessage([]byte(`{"text":"e","codes":["x"],"options":{"hz":800,"wpm":10,"volume":1}}`)); err == nil {
+		t.Errorf("should fail with invalid codes")
+	}
+	if _, _, _, err := UnmarshalMessage([]byte(`{"text":"e","codes":["."],"options":{}}`)); err == nil {
+		t.Errorf("should fail with invalid options")
+	}
+	if _, _, _, err := UnmarshalMessage([]byte(`{`)); err == nil {
+		t.Errorf("should fail with malformed JSON")
+	}
+}
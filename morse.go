@@ -1,10 +1,13 @@
 package morse
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
@@ -23,14 +26,14 @@ const (
 	Dah Duration = "−" // long
 )
 
-// constants for beep sounds
+// default constants for beep sounds
 const (
 	hz  = 800
 	wpm = 10
 
-	durationShort = 1200 / wpm
-	durationLong  = durationShort * 3
-	durationGap   = durationShort * 2
+	defaultSampleRate     = 44100
+	defaultRamp           = 5 * time.Millisecond
+	defaultBufferDuration = time.Second / 100
 )
 
 // Code for morse code strings
@@ -78,6 +81,33 @@ const (
 	Nine  Code = Code(Dah + Dah + Dah + Dah + Dit)
 	Zero  Code = Code(Dah + Dah + Dah + Dah + Dah)
 
+	// punctuations
+	Period           Code = Code(Dit + Dah + Dit + Dah + Dit + Dah)
+	Comma            Code = Code(Dah + Dah + Dit + Dit + Dah + Dah)
+	QuestionMark     Code = Code(Dit + Dit + Dah + Dah + Dit + Dit)
+	Apostrophe       Code = Code(Dit + Dah + Dah + Dah + Dah + Dit)
+	ExclamationMark  Code = Code(Dah + Dit + Dah + Dit + Dah + Dah)
+	Slash            Code = Code(Dah + Dit + Dit + Dah + Dit)
+	ParenthesisOpen  Code = Code(Dah + Dit + Dah + Dah + Dit)
+	ParenthesisClose Code = Code(Dah + Dit + Dah + Dah + Dit + Dah)
+	Colon            Code = Code(Dah + Dah + Dah + Dit + Dit + Dit)
+	QuotationMark    Code = Code(Dit + Dah + Dit + Dit + Dah + Dit)
+	Equals           Code = Code(Dah + Dit + Dit + Dit + Dah)
+	Plus             Code = Code(Dit + Dah + Dit + Dah + Dit)
+	Hyphen           Code = Code(Dah + Dit + Dit + Dit + Dit + Dah)
+	AtSign           Code = Code(Dit + Dah + Dah + Dit + Dah + Dit)
+
+	// accented letters (ITU extended set)
+	//
+	// 'á' and 'å' share the code of 'à', so only 'à' is in the codes map.
+	AGrave     Code = Code(Dit + Dah + Dah + Dit + Dah) // à
+	ADiaeresis Code = Code(Dit + Dah + Dit + Dah)       // ä
+	CCedilla   Code = Code(Dah + Dit + Dah + Dit + Dit) // ç
+	EAcute     Code = Code(Dit + Dit + Dah + Dit + Dit) // é
+	NTilde     Code = Code(Dah + Dah + Dit + Dah + Dah) // ñ
+	ODiaeresis Code = Code(Dah + Dah + Dah + Dit)       // ö
+	UDiaeresis Code = Code(Dit + Dit + Dah + Dah)       // ü
+
 	Space Code = " "
 	None  Code = ""
 )
@@ -92,10 +122,66 @@ func CodeFromDurations(durations ...Duration) Code {
 	return Code(strings.Join(strs, ""))
 }
 
+// replacer for rendering codes with ASCII dots and dashes
+var asciiReplacer = strings.NewReplacer(string(Dit), ".", string(Dah), "-")
+
+// String renders the code with ASCII dots ('.') and dashes ('-'), eg. ".-" for `A`.
+func (c Code) String() string {
+	return asciiReplacer.Replace(string(c))
+}
+
+// Symbols returns the number of `Dit`s and `Dah`s in the code.
+func (c Code) Symbols() (dits, dahs int) {
+	for _, chr := range c {
+		switch chr {
+		case ditRune:
+			dits++
+		case dahRune:
+			dahs++
+		}
+	}
+
+	return dits, dahs
+}
+
+// Valid returns whether the code consists of `Dit`s and `Dah`s only.
+//
+// `None` and `Space` are not valid, as they have no durations.
+func (c Code) Valid() bool {
+	if c == None {
+		return false
+	}
+
+	for _, chr := range c {
+		if chr != ditRune && chr != dahRune {
+			return false
+		}
+	}
+
+	return true
+}
+
 // map for codes and characters
 var codesMap map[rune]Code
 var charsMap map[Code]rune
 
+// for guarding the maps (and the bitmask table) over registrations
+var mapsLock sync.RWMutex
+
+// for initializing the speaker
+var (
+	speakerInit       = speaker.Init  // replaceable for testing
+	speakerSampleRate beep.SampleRate // sample rate of the last initialization
+	speakerBufferSize int             // buffer size of the last initialization
+	speakerLock       sync.Mutex
+)
+
+// for playing sounds with the speaker, replaceable for testing
+var (
+	speakerPlay  = speaker.Play
+	speakerClear = speaker.Clear
+)
+
 // regular expression for non-encodable strings
 var regexToEscape *regexp.Regexp
 var regexRedundantSpaces *regexp.Regexp
@@ -142,6 +228,30 @@ func init() {
 		'9': Nine,
 		'0': Zero,
 
+		'.':  Period,
+		',':  Comma,
+		'?':  QuestionMark,
+		'\'': Apostrophe,
+		'!':  ExclamationMark,
+		'/':  Slash,
+		'(':  ParenthesisOpen,
+		')':  ParenthesisClose,
+		':':  Colon,
+		'"':  QuotationMark,
+		'=':  Equals,
+		'+':  Plus,
+		'-':  Hyphen,
+		'@':  AtSign,
+
+		// accented letters
+		'à': AGrave,
+		'ä': ADiaeresis,
+		'ç': CCedilla,
+		'é': EAcute,
+		'ñ': NTilde,
+		'ö': ODiaeresis,
+		'ü': UDiaeresis,
+
 		' ': Space,
 	}
 
@@ -151,44 +261,232 @@ func init() {
 		charsMap[v] = k
 	}
 
-	regexToEscape = regexp.MustCompile("[^a-zA-Z0-9\\s]+")
+	regexToEscape = regexp.MustCompile("[^a-zA-Z0-9àäçéñöüÀÄÇÉÑÖÜ\\s.,?'!/():\"=+\\-@]+")
 	regexRedundantSpaces = regexp.MustCompile("\\s{2,}")
+
+	// bitmask table for fast decoding
+	buildBitmaskTable()
 }
 
 // Encode encodes morse codes from given `text`.
 //
+// Consecutive whitespaces are encoded as a single `Space`, so `text` is encoded in the same way as its `Escape`d one.
+//
 // Will return an error when given `text` includes non-encodable characters.
 func Encode(text string) (codes []Code, err error) {
-	codes = []Code{}
+	return defaultEncoder.Encode(text)
+}
+
+// ZeroStyle for representations of the digit zero
+type ZeroStyle int
 
-	if _, err = Encodable(text); err == nil {
-		for _, chr := range strings.ToLowerSpecial(unicode.TurkishCase, text) {
-			if code, err := charToCode(chr); err == nil {
-				codes = append(codes, code)
+// Representations of the digit zero
+const (
+	ZeroLong  ZeroStyle = iota // five dahs (`Zero`), the standard one
+	ZeroShort                  // a single dah, which is the same as `T` (so it is decoded as 't', or by context with `DecodeCutNumbers`)
+)
+
+// EncodeOptions for configuring encoding
+type EncodeOptions struct {
+	Zero ZeroStyle // representation of '0'
+
+	// whether to fold accented letters without their own codes (eg. 'á' or 'ê') to their base letters,
+	// while the ones with their own codes (eg. 'é' or 'ü') are encoded as they are
+	FoldAccents bool
+
+	// whether to insert `ProsignAR` (end of message) after each sentence-ending punctuation ('.', '!', or '?')
+	// which is followed by a whitespace or the end of text
+	AutoProsigns bool
+}
+
+// EncodeWith encodes morse codes from given `text` with given `opts`.
+//
+// Will return an error when given `text` includes non-encodable characters.
+func EncodeWith(text string, opts EncodeOptions) (codes []Code, err error) {
+	if opts.FoldAccents {
+		text = Fold(text)
+	}
+	text = collapseSpaces(text)
+
+	if codes, err = Encode(text); err != nil {
+		return codes, err
+	}
+
+	if opts.Zero == ZeroShort {
+		for i, code := range codes {
+			if code == Zero {
+				codes[i] = T
 			}
 		}
-	} else {
-		err = fmt.Errorf("'%s' is not encodable: %s", text, err)
 	}
 
-	return codes, err
+	if opts.AutoProsigns {
+		codes = insertProsigns(text, codes)
+	}
+
+	return codes, nil
+}
+
+// EncodeLenient encodes morse codes from given `text`, just like `Encode`,
+// but silently skips non-encodable characters instead of returning an error.
+//
+// Spaces are preserved as they are (each one as a `Space`), and nothing is inserted for the skipped characters.
+func EncodeLenient(text string) (codes []Code) {
+	codes = []Code{}
+
+	for _, chr := range strings.ToLowerSpecial(unicode.TurkishCase, text) {
+		if code, err := charToCode(chr); err == nil {
+			codes = append(codes, code)
+		}
+	}
+
+	return codes
+}
+
+// EncodeCallback encodes morse codes from given `text`, and calls `fn` for each encoded character
+// (with its index, the character in lower case, and its code) instead of collecting them in a slice.
+//
+// Will return an error without calling `fn` when given `text` includes non-encodable characters.
+func EncodeCallback(text string, fn func(i int, chr rune, code Code)) (err error) {
+	if _, err = Encodable(text); err != nil {
+		return fmt.Errorf("'%s' is not encodable: %s", text, err)
+	}
+
+	for i, chr := range []rune(strings.ToLowerSpecial(unicode.TurkishCase, text)) {
+		var code Code
+		if code, err = charToCode(chr); err != nil {
+			// possible only when the code was unregistered after the check above
+			return fmt.Errorf("'%s' is not encodable: %s", text, err)
+		}
+
+		fn(i, chr, code)
+	}
+
+	return nil
 }
 
 // Decode decodes given morse `codes` to a string.
+//
+// Each `Space` is decoded as a ' ', so consecutive ones are decoded as consecutive spaces (see `DecodeWords`).
 func Decode(codes []Code) (decoded string, err error) {
+	return defaultEncoder.Decode(codes)
+}
+
+// DecodeWords decodes given morse `codes` to words separated by single spaces.
+//
+// Glyphs of codes are normalized (eg. '.' and '-'), redundant (leading, trailing, and consecutive) `Space`s are collapsed,
+// and non-decodable codes are silently skipped.
+func DecodeWords(codes []Code) string {
+	decodable := []Code{}
+	for _, code := range normalizeCodes(codes) {
+		if _, err := codeToChar(code); err == nil {
+			decodable = append(decodable, code)
+		}
+	}
+
+	// collapse again, for `Space`s around the skipped ones
 	chars := []rune{}
+	for _, code := range normalizeCodes(decodable) {
+		chr, _ := codeToChar(code)
+		chars = append(chars, chr)
+	}
+
+	return string(chars)
+}
+
+// CutNumberContext for disambiguating cut zeros (a single dah, see `ZeroShort`) from 't's by their surrounding characters
+type CutNumberContext int
+
+// Policies of disambiguating cut zeros
+const (
+	CutNumberNone      CutNumberContext = iota // always decoded as 't', the same as `Decode`
+	CutNumberNeighbors                         // decoded as '0' in a run of 't's next to a digit in the same word (eg. "5tt" to "500")
+	CutNumberNumeric                           // decoded as '0' in a word of digits and 't's only, with at least one digit (eg. "t5t" to "050")
+)
 
-	if _, err = Decodable(codes); err == nil {
-		for _, code := range codes {
-			if chr, err := codeToChar(code); err == nil {
-				chars = append(chars, chr)
+// DecodeCutNumbers decodes given morse `codes` to a string, just like `Decode`,
+// but decodes `T`s which are cut zeros (see `ZeroShort`) as '0' by their context with given `policy`.
+//
+// Will return an error when given `codes` include non-decodable ones, or `policy` is unknown.
+func DecodeCutNumbers(codes []Code, policy CutNumberContext) (decoded string, err error) {
+	if decoded, err = Decode(codes); err != nil {
+		return "", err
+	}
+
+	chars := []rune(decoded)
+	isDigit := func(i int) bool {
+		return i >= 0 && i < len(chars) && chars[i] >= '0' && chars[i] <= '9'
+	}
+
+	switch policy {
+	case CutNumberNone:
+	case CutNumberNeighbors:
+		for start := 0; start < len(chars); start++ {
+			if chars[start] != 't' {
+				continue
+			}
+
+			end := start
+			for end < len(chars) && chars[end] == 't' {
+				end++
+			}
+			if isDigit(start-1) || isDigit(end) {
+				for i := start; i < end; i++ {
+					chars[i] = '0'
+				}
+			}
+			start = end
+		}
+	case CutNumberNumeric:
+		for start := 0; start < len(chars); start++ {
+			end, digits := start, false
+			for end < len(chars) && (chars[end] == 't' || isDigit(end)) {
+				digits = digits || isDigit(end)
+				end++
 			}
+
+			// only for whole words
+			if digits && (start == 0 || chars[start-1] == ' ') && (end == len(chars) || chars[end] == ' ') {
+				for i := start; i < end; i++ {
+					if chars[i] == 't' {
+						chars[i] = '0'
+					}
+				}
+			}
+			for end < len(chars) && chars[end] != ' ' {
+				end++
+			}
+			start = end
 		}
-	} else {
-		err = fmt.Errorf("'%v' are not decodable: %s", codes, err)
+	default:
+		return "", fmt.Errorf("unknown policy of cut numbers: %d", policy)
 	}
 
-	return string(chars), err
+	return string(chars), nil
+}
+
+// EncodeReport encodes morse codes from encodable characters of given `text`,
+// and reports all non-encodable ones (in the order of their appearances) instead of failing on the first one.
+//
+// Returned `err` lists the non-encodable characters with their positions (in runes), and is nil when there is none.
+func EncodeReport(text string) (codes []Code, invalid []rune, err error) {
+	codes, invalid = []Code{}, []rune{}
+
+	positions := []string{}
+	for i, chr := range []rune(text) {
+		if code, err := charToCode(unicode.TurkishCase.ToLower(chr)); err == nil {
+			codes = append(codes, code)
+		} else {
+			invalid = append(invalid, chr)
+			positions = append(positions, fmt.Sprintf("'%c' at %d", chr, i))
+		}
+	}
+
+	if len(invalid) > 0 {
+		err = fmt.Errorf("'%s' has non-encodable characters: %s", text, strings.Join(positions, ", "))
+	}
+
+	return codes, invalid, err
 }
 
 // Encodable returns whether given `text` is encodable or not.
@@ -215,50 +513,618 @@ func Decodable(codes []Code) (decodable bool, err error) {
 
 // Escape returns `text` with non-encodable characters and redundant spaces removed/replaced.
 func Escape(text string) string {
-	return regexRedundantSpaces.ReplaceAllString(regexToEscape.ReplaceAllString(text, ""), " ")
+	return collapseSpaces(regexToEscape.ReplaceAllString(text, ""))
 }
 
-// Beep plays sounds for given `codes` synchronously.
+// replaces consecutive whitespaces in given `text` with a single space.
+func collapseSpaces(text string) string {
+	return regexRedundantSpaces.ReplaceAllString(text, " ")
+}
+
+// RampShape for shapes of the attack and release of tones
+type RampShape int
+
+// Shapes of the attack and release of tones
+const (
+	RampRaisedCosine RampShape = iota // smooth, with narrow sidebands
+	RampLinear                        // harder, with wider sidebands
+)
+
+// RoundingMode is a way of converting elapsed times to numbers of samples
+type RoundingMode int
+
+// Modes of rounding durations to samples
+const (
+	RoundTruncate    RoundingMode = iota // toward zero, up to a sample behind the exact time
+	RoundNearest                         // to the nearest sample, halves away from zero
+	RoundNearestEven                     // to the nearest sample, halves to the even one
+)
+
+// BeepOptions for configuring beep sounds
+type BeepOptions struct {
+	Hz     int     `json:"hz"`     // frequency of the tone
+	WPM    int     `json:"wpm"`    // speed in words per minute (PARIS standard)
+	Volume float64 `json:"volume"` // amplitude of the tone, 0.0 ~ 1.0
+
+	SampleRate int           `json:"sample_rate"` // sample rate of rendered sounds (44100 when zero)
+	Ramp       time.Duration `json:"ramp"`        // length of the attack and release of each tone, for avoiding clicks (none when zero)
+	RampShape  RampShape     `json:"ramp_shape"`  // shape of the attack and release of each tone (raised cosine when zero)
+	Rounding   RoundingMode  `json:"rounding"`    // rounding of the elapsed time at each boundary of tones and gaps to samples (truncated when zero)
+	BitDepth   BitDepth      `json:"bit_depth"`   // bit depth of samples in WAV files (16-bit PCM when zero)
+
+	// lengths of the fade in and out of the whole message, for gentle beacons (none when zero)
+	MessageFadeIn  time.Duration `json:"message_fade_in"`
+	MessageFadeOut time.Duration `json:"message_fade_out"`
+
+	// for Farnsworth timing: characters are sent at `CharWPM`, and gaps between them are stretched to `EffectiveWPM`
+	// (when zero, `WPM` and `CharWPM` are used respectively)
+	CharWPM      int `json:"char_wpm"`
+	EffectiveWPM int `json:"effective_wpm"`
+
+	BufferDuration time.Duration `json:"buffer_duration"` // length of the speaker's buffer, for balancing glitches and latency (10 ms when zero)
+
+	// extra units of gap between a letter and a digit next to each other (eg. "AB12"), for formats which separate them
+	LetterDigitGap int `json:"letter_digit_gap"`
+
+	// callsign (eg. "HL1ABC") to be sent with tighter gaps of 2 units between its characters, wherever it appears in codes
+	Callsign string `json:"callsign"`
+
+	PreambleDits int `json:"preamble_dits"` // number of dits sent before the message for attention, separated like characters and followed by a word gap
+	RepeatEach   int `json:"repeat_each"`   // number of times each character is sent, separated with gaps between characters (once when zero), for drills
+}
+
+// DefaultBeepOptions returns the default options for beep sounds (800 Hz, 10 WPM, full volume, at 44100 Hz, with ramps of 5 ms).
+func DefaultBeepOptions() BeepOptions {
+	return BeepOptions{
+		Hz:         hz,
+		WPM:        wpm,
+		Volume:     1.0,
+		SampleRate: defaultSampleRate,
+		Ramp:       defaultRamp,
+	}
+}
+
+// validates options
+func (o BeepOptions) validate() error {
+	if o.Hz <= 0 {
+		return fmt.Errorf("frequency should be positive: %d", o.Hz)
+	}
+	if o.WPM <= 0 {
+		return fmt.Errorf("WPM should be positive: %d", o.WPM)
+	}
+	if o.Volume < 0 || o.Volume > 1 {
+		return fmt.Errorf("volume should be between 0.0 and 1.0: %f", o.Volume)
+	}
+	if o.SampleRate < 0 {
+		return fmt.Errorf("sample rate should not be negative: %d", o.SampleRate)
+	}
+	if o.Ramp < 0 {
+		return fmt.Errorf("ramp should not be negative: %s", o.Ramp)
+	}
+	if o.RampShape != RampRaisedCosine && o.RampShape != RampLinear {
+		return fmt.Errorf("unknown shape of ramps: %d", o.RampShape)
+	}
+	if o.Rounding != RoundTruncate && o.Rounding != RoundNearest && o.Rounding != RoundNearestEven {
+		return fmt.Errorf("unknown rounding mode: %d", o.Rounding)
+	}
+	if o.BitDepth != 0 {
+		if err := validateBitDepth(o.BitDepth); err != nil {
+			return err
+		}
+	}
+	if o.MessageFadeIn < 0 || o.MessageFadeOut < 0 {
+		return fmt.Errorf("fades of the message should not be negative: %s / %s", o.MessageFadeIn, o.MessageFadeOut)
+	}
+	if o.LetterDigitGap < 0 {
+		return fmt.Errorf("extra gap between letters and digits should not be negative: %d", o.LetterDigitGap)
+	}
+	if o.RepeatEach < 0 {
+		return fmt.Errorf("number of repeats should not be negative: %d", o.RepeatEach)
+	}
+	if o.PreambleDits < 0 {
+		return fmt.Errorf("number of preamble dits should not be negative: %d", o.PreambleDits)
+	}
+	if o.Callsign != "" {
+		if callsign, err := o.callsignCodes(); err != nil || slices.Contains(callsign, Space) {
+			return fmt.Errorf("callsign should be a single encodable word: '%s'", o.Callsign)
+		}
+	}
+	if o.BufferDuration < 0 {
+		return fmt.Errorf("buffer duration should not be negative: %s", o.BufferDuration)
+	}
+	if o.CharWPM < 0 || o.EffectiveWPM < 0 {
+		return fmt.Errorf("WPMs for Farnsworth timing should not be negative: %d / %d", o.CharWPM, o.EffectiveWPM)
+	}
+	if charWPM, effectiveWPM := o.farnsworthWPMs(); effectiveWPM > charWPM {
+		return fmt.Errorf("effective WPM should not be faster than character WPM: %d / %d", effectiveWPM, charWPM)
+	}
+
+	return nil
+}
+
+// returns the sample rate of rendered sounds
+func (o BeepOptions) sampleRate() beep.SampleRate {
+	if o.SampleRate > 0 {
+		return beep.SampleRate(o.SampleRate)
+	}
+	return defaultSampleRate
+}
+
+// returns the bit depth of WAV files, or 16 bits if not set
+func (o BeepOptions) bitDepth() BitDepth {
+	if o.BitDepth != 0 {
+		return o.BitDepth
+	}
+	return BitDepth16
+}
+
+// returns the number of samples for given duration `d` (of a gap, or elapsed from the start of a message), rounded with the mode of `o`
+func (o BeepOptions) samples(sr beep.SampleRate, d time.Duration) int {
+	exact := d.Seconds() * float64(sr)
+
+	switch o.Rounding {
+	case RoundNearest:
+		return int(math.Round(exact))
+	case RoundNearestEven:
+		return int(math.RoundToEven(exact))
+	default:
+		return int(exact)
+	}
+}
+
+// returns codes of the callsign
+//
+// It is lowered without the special case of Turkish, as callsigns are usually written in upper case (eg. "DL1IA").
+func (o BeepOptions) callsignCodes() ([]Code, error) {
+	return Encode(strings.ToLower(o.Callsign))
+}
+
+// returns the length of the speaker's buffer
+func (o BeepOptions) bufferDuration() time.Duration {
+	if o.BufferDuration > 0 {
+		return o.BufferDuration
+	}
+	return defaultBufferDuration
+}
+
+// returns the character and effective speeds of Farnsworth timing
+func (o BeepOptions) farnsworthWPMs() (charWPM, effectiveWPM int) {
+	charWPM = o.WPM
+	if o.CharWPM > 0 {
+		charWPM = o.CharWPM
+	}
+	effectiveWPM = charWPM
+	if o.EffectiveWPM > 0 {
+		effectiveWPM = o.EffectiveWPM
+	}
+
+	return charWPM, effectiveWPM
+}
+
+// returns durations of a unit, a gap between characters, and a gap between words.
+//
+// When the character and effective speeds differ, gaps are computed with the ARRL Farnsworth formula;
+// otherwise they are 3 and 7 units of the uniform timing.
+func (o BeepOptions) timings() (unit, charGap, wordGap time.Duration) {
+	charWPM, effectiveWPM := o.farnsworthWPMs()
+
+	return farnsworthTimings(float64(charWPM), float64(effectiveWPM))
+}
+
+// returns durations of a unit, a gap between characters, and a gap between words
+// at given character and effective speeds (see `BeepOptions.timings`).
+func farnsworthTimings(charWPM, effectiveWPM float64) (unit, charGap, wordGap time.Duration) {
+	unit = unitDuration(charWPM)
+	if charWPM == effectiveWPM {
+		return unit, unit * unitsCharGap, unit * unitsWordGap
+	}
+
+	// total delay of the 19 units of gaps in "PARIS ", in seconds
+	c, s := charWPM, effectiveWPM
+	delay := (60*c - 37.2*s) / (c * s)
+
+	charGap = time.Duration(math.Round(delay * unitsCharGap / 19 * float64(time.Second)))
+	wordGap = time.Duration(math.Round(delay * unitsWordGap / 19 * float64(time.Second)))
+
+	return unit, charGap, wordGap
+}
+
+// Beep plays sounds for given `codes` synchronously, with the default options.
 func Beep(codes []Code) {
-	sr := beep.SampleRate(44100)
-	speaker.Init(sr, sr.N(time.Second/100))
+	_ = defaultEncoder.Beep(codes)
+}
 
-	done := make(chan bool)
-	for i, code := range codes {
+// BeepWith plays sounds for given `codes` synchronously, with given `opts`,
+// in the same timing as `Samples` and `Timeline`.
+//
+// Will return an error when `codes` or `opts` are not valid.
+func BeepWith(codes []Code, opts BeepOptions) (err error) {
+	return beepContext(context.Background(), codes, opts)
+}
+
+// BeepContext plays sounds for given `codes` synchronously, with the default options.
+//
+// Playback stops as soon as `ctx` is canceled, and `ctx.Err()` is returned.
+func BeepContext(ctx context.Context, codes []Code) (err error) {
+	return beepContext(ctx, codes, DefaultBeepOptions())
+}
+
+// BeepChar plays sounds for given character `chr` synchronously, with given `opts`.
+//
+// `chr` is lowered in the same way as `Encode`, so 'I' is lowered to the dotless 'ı' (not encodable) and 'İ' to 'i'.
+//
+// Will return an error when `chr` is not encodable or `opts` are not valid.
+func BeepChar(chr rune, opts BeepOptions) (err error) {
+	var code Code
+	if code, err = charToCode(lowerChar(chr)); err != nil {
+		return fmt.Errorf("'%c' is not encodable: %s", chr, err)
+	}
+
+	return beepContext(context.Background(), []Code{code}, opts)
+}
+
+// RepeatMarker is a distinctive signal sent between repetitions of a message in `BeepRepeat`
+type RepeatMarker struct {
+	Codes []Code // codes of the marker, eg. a prosign from `EncodeProsign` (no marker when empty)
+	Hz    int    // frequency of the marker, for telling it from the message (the frequency of `BeepOptions` when zero)
+}
+
+// BeepRepeat plays sounds for given `codes` `times` times synchronously with given `opts`,
+// with `marker` sent between repetitions (not before the first one), separated from them with gaps between words.
+//
+// Playback stops as soon as `ctx` is canceled, and `ctx.Err()` is returned.
+//
+// Will return an error when `codes`, `marker`, or `opts` are not valid, or `times` is not positive.
+func BeepRepeat(ctx context.Context, codes []Code, times int, marker RepeatMarker, opts BeepOptions) (err error) {
+	var streamer beep.Streamer
+	if streamer, err = streamRepeat(codes, times, marker, opts); err != nil {
+		return err
+	}
+
+	return playContext(ctx, streamer, opts)
+}
+
+// plays sounds for given `codes` until done or `ctx` is canceled
+func beepContext(ctx context.Context, codes []Code, opts BeepOptions) (err error) {
+	if err = opts.validate(); err != nil {
+		return err
+	}
+
+	var streamer beep.Streamer
+	if streamer, err = streamCodes(codes, opts.sampleRate(), opts); err != nil {
+		return err
+	}
+
+	return playContext(ctx, streamer, opts)
+}
+
+// returns a stream of sounds for given `codes` repeated `times` times with `marker` between them (see `BeepRepeat`)
+func streamRepeat(codes []Code, times int, marker RepeatMarker, opts BeepOptions) (streamer beep.Streamer, err error) {
+	if err = opts.validate(); err != nil {
+		return nil, err
+	}
+	if times <= 0 {
+		return nil, fmt.Errorf("number of repetitions should be positive: %d", times)
+	}
+	if marker.Hz < 0 {
+		return nil, fmt.Errorf("frequency of the marker should not be negative: %d", marker.Hz)
+	}
+
+	sr := opts.sampleRate()
+	_, _, wordGap := opts.timings()
+	gap := opts.samples(sr, wordGap)
+
+	// options of the whole message are not applied to the marker
+	markerOpts := opts
+	markerOpts.PreambleDits, markerOpts.RepeatEach, markerOpts.Callsign = 0, 0, ""
+	markerOpts.MessageFadeIn, markerOpts.MessageFadeOut = 0, 0
+	if marker.Hz > 0 {
+		markerOpts.Hz = marker.Hz
+	}
+
+	streamers := []beep.Streamer{}
+	for i := 0; i < times; i++ {
 		if i > 0 {
-			time.Sleep(durationGap * time.Millisecond)
-		}
+			streamers = append(streamers, beep.Silence(gap))
 
-		for _, chr := range code {
-			var duration int
-			switch Duration(chr) {
-			case Dit:
-				duration = durationShort
-			case Dah:
-				duration = durationLong
+			if len(marker.Codes) > 0 {
+				var markerStreamer beep.Streamer
+				if markerStreamer, err = streamCodes(marker.Codes, sr, markerOpts); err != nil {
+					return nil, fmt.Errorf("invalid marker: %s", err)
+				}
+				streamers = append(streamers, markerStreamer, beep.Silence(gap))
 			}
+		}
 
-			speaker.Play(beep.Seq(beep.Take(sr.N(time.Duration(duration)*time.Millisecond), beeper()), beep.Callback(func() {
-				done <- true
-			})))
-			<-done
+		var messageStreamer beep.Streamer
+		if messageStreamer, err = streamCodes(codes, sr, opts); err != nil {
+			return nil, err
 		}
+		streamers = append(streamers, messageStreamer)
+	}
+
+	return beep.Seq(streamers...), nil
+}
+
+// plays given `streamer` rendered with `opts` until done or `ctx` is canceled
+func playContext(ctx context.Context, streamer beep.Streamer, opts BeepOptions) (err error) {
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	if err = initSpeaker(opts.sampleRate(), opts.bufferDuration()); err != nil {
+		return err
+	}
+
+	// buffered, so the callback does not block when canceled
+	done := make(chan bool, 1)
+
+	speakerPlay(beep.Seq(streamer, beep.Callback(func() {
+		done <- true
+	})))
+
+	select {
+	case <-ctx.Done():
+		// cut the remaining sound
+		speakerClear()
+		return ctx.Err()
+	case <-done:
 	}
+
+	return nil
 }
 
-// beep sound stream
-func beeper() beep.Streamer {
+// initializes the speaker with given sample rate and buffer duration,
+// only when it was not initialized yet or initialized with a different sample rate or buffer size
+func initSpeaker(sr beep.SampleRate, buffer time.Duration) error {
+	speakerLock.Lock()
+	defer speakerLock.Unlock()
+
+	bufferSize := sr.N(buffer)
+	if speakerSampleRate == sr && speakerBufferSize == bufferSize {
+		return nil
+	}
+
+	if err := speakerInit(sr, bufferSize); err != nil {
+		return fmt.Errorf("failed to initialize speaker: %s", err)
+	}
+	speakerSampleRate, speakerBufferSize = sr, bufferSize
+
+	return nil
+}
+
+// returns a stream of sounds for given `codes` with `opts`, in the standard timing
+// (1 unit for dits and gaps in characters, 3 units for dahs and gaps between characters, and 7 units for gaps between words),
+// or with gaps between characters and words stretched for Farnsworth timing (and other gaps of `opts`).
+func streamCodes(codes []Code, sr beep.SampleRate, opts BeepOptions) (streamer beep.Streamer, err error) {
+	return streamCodesAt(codes, sr, opts, 0)
+}
+
+// returns a stream of sounds for given `codes` with `opts` just like `streamCodes`,
+// but starting at `offset` of a longer message, so boundaries are rounded from the time elapsed in it.
+func streamCodesAt(codes []Code, sr beep.SampleRate, opts BeepOptions, offset time.Duration) (streamer beep.Streamer, err error) {
+	var signals []Signal
+	if signals, err = opts.schedule(codes, nil); err != nil {
+		return nil, err
+	}
+
+	ramp := sr.N(opts.Ramp)
+
+	// a tone is shared by the segments, and restarts from a zero crossing in each of them
+	t := beeper(opts.Hz, opts.Volume, sr).(*tone)
+
+	// boundaries are rounded from the elapsed time, so errors do not accumulate over signals
+	streamers := []beep.Streamer{}
+	elapsed, start := offset, opts.samples(sr, offset)
+	total := 0
+	for _, signal := range signals {
+		elapsed += signal.Duration
+		samples := opts.samples(sr, elapsed) - start - total
+		if signal.On {
+			streamers = append(streamers, beep.Callback(t.reset), envelope(t, samples, ramp, opts.RampShape))
+		} else {
+			streamers = append(streamers, beep.Silence(samples))
+		}
+		total += samples
+	}
+
+	streamer = beep.Seq(streamers...)
+	if opts.MessageFadeIn > 0 || opts.MessageFadeOut > 0 {
+		streamer = ramps(streamer, total, sr.N(opts.MessageFadeIn), sr.N(opts.MessageFadeOut), RampRaisedCosine)
+	}
+
+	return streamer, nil
+}
+
+// Samples returns the whole stereo samples of sounds for given `codes` with `opts`, including silences of gaps,
+// at the sample rate of `opts`.
+//
+// Returns nil when `codes` or `opts` are not valid.
+func Samples(codes []Code, opts BeepOptions) (samples [][2]float64) {
+	samples, _ = renderCodes(codes, opts)
+	return samples
+}
+
+// renders the whole samples of sounds for given `codes` with `opts`
+func renderCodes(codes []Code, opts BeepOptions) (samples [][2]float64, err error) {
+	return renderCodesAt(codes, opts, 0)
+}
+
+// renders the whole samples of sounds for given `codes` with `opts`, starting at `offset` of a longer message
+func renderCodesAt(codes []Code, opts BeepOptions, offset time.Duration) (samples [][2]float64, err error) {
+	if err = opts.validate(); err != nil {
+		return nil, err
+	}
+
+	var streamer beep.Streamer
+	if streamer, err = streamCodesAt(codes, opts.sampleRate(), opts, offset); err != nil {
+		return nil, err
+	}
+
+	samples = [][2]float64{}
+	buf := make([][2]float64, 512)
+	for {
+		n, ok := streamer.Stream(buf)
+		samples = append(samples, buf[:n]...)
+		if !ok {
+			break
+		}
+	}
+
+	return samples, nil
+}
+
+// takes `total` samples from given `streamer`, with attack and release of `ramp` samples in given `shape`
+// (shortened to a half of `total` for short tones).
+func envelope(streamer beep.Streamer, total, ramp int, shape RampShape) beep.Streamer {
+	return ramps(streamer, total, ramp, ramp, shape)
+}
+
+// takes `total` samples from given `streamer`, with `attack` and `release` (in samples) in given `shape`,
+// each shortened to a half of `total` for short streams.
+func ramps(streamer beep.Streamer, total, attack, release int, shape RampShape) beep.Streamer {
+	gainAt := func(pos, length int) float64 {
+		if shape == RampLinear {
+			return float64(pos) / float64(length)
+		}
+		return (1 - math.Cos(math.Pi*float64(pos)/float64(length))) / 2
+	}
+
+	attack, release = min(attack, total/2), min(release, total/2)
+
+	pos := 0
 	return beep.StreamerFunc(func(samples [][2]float64) (n int, ok bool) {
-		for i := range samples {
-			samples[i][0] = math.Sin(float64(i) * math.Pi * 2 * hz / 44100)
-			samples[i][1] = math.Sin(float64(i) * math.Pi * 2 * hz / 44100)
+		if pos >= total {
+			return 0, false
 		}
-		return len(samples), true
+
+		n, ok = streamer.Stream(samples[:min(len(samples), total-pos)])
+		for i := 0; i < n; i, pos = i+1, pos+1 {
+			gain := 1.0
+			if pos < attack {
+				gain = gainAt(pos, attack)
+			} else if from := total - 1 - pos; from < release {
+				gain = gainAt(from, release)
+			}
+
+			samples[i][0] *= gain
+			samples[i][1] *= gain
+		}
+
+		return n, ok || n > 0
 	})
 }
 
-// converts given character to a morse code.
+// beep sound stream of given frequency and volume
+//
+// Its phase accumulates over the calls of `Stream`, so the tone stays continuous between buffers,
+// and it starts from a zero crossing so that it joins a preceding silence without a jump.
+func beeper(hz int, volume float64, sr beep.SampleRate) beep.Streamer {
+	return &tone{
+		step:   math.Pi * 2 * float64(hz) / float64(sr),
+		volume: volume,
+	}
+}
+
+// phase accumulator of a sine tone
+type tone struct {
+	phase  float64
+	step   float64
+	volume float64
+}
+
+// Stream streams samples of the tone, continuing from the phase of the last call.
+func (t *tone) Stream(samples [][2]float64) (n int, ok bool) {
+	for i := range samples {
+		v := t.volume * math.Sin(t.phase)
+		samples[i][0], samples[i][1] = v, v
+
+		// wrap around for keeping the precision over long streams
+		if t.phase += t.step; t.phase >= math.Pi*2 {
+			t.phase -= math.Pi * 2
+		}
+	}
+	return len(samples), true
+}
+
+// Err returns no error, as the tone never fails.
+func (t *tone) Err() error {
+	return nil
+}
+
+// reset resets the phase, so the next sample starts from a zero crossing.
+func (t *tone) reset() {
+	t.phase = 0
+}
+
+// RegisterCode registers given `code` for character `chr`, overriding its existing code if any.
+//
+// `chr` is registered in lower case, as texts are lowered before encoding.
+// Will return an error when `code` is not made of `Dit`s and `Dah`s only,
+// is longer than 10 durations (so it cannot be decoded with `DecodeFast`),
+// or it is already used by another character.
+func RegisterCode(chr rune, code Code) (err error) {
+	if code == None {
+		return fmt.Errorf("cannot register an empty code for '%c'", chr)
+	}
+	if n := len([]rune(code)); n > maxBitmaskDurations {
+		return fmt.Errorf("code '%s' for '%c' is too long: %d durations (max: %d)", code, chr, n, maxBitmaskDurations)
+	}
+	for _, d := range code {
+		if d != ditRune && d != dahRune {
+			return fmt.Errorf("not a valid duration in code '%s' for '%c': '%c'", code, chr, d)
+		}
+	}
+
+	chr = unicode.TurkishCase.ToLower(chr)
+
+	mapsLock.Lock()
+	defer mapsLock.Unlock()
+
+	if existing, exists := charsMap[code]; exists && existing != chr {
+		return fmt.Errorf("code '%s' for '%c' is already used by '%c'", code, chr, existing)
+	}
+
+	if old, exists := codesMap[chr]; exists {
+		delete(charsMap, old)
+	}
+	codesMap[chr] = code
+	charsMap[code] = chr
+
+	buildBitmaskTable()
+
+	return nil
+}
+
+// UnregisterCode unregisters the code of character `chr` (in lower case), if any.
+func UnregisterCode(chr rune) {
+	chr = unicode.TurkishCase.ToLower(chr)
+
+	mapsLock.Lock()
+	defer mapsLock.Unlock()
+
+	if code, exists := codesMap[chr]; exists {
+		delete(codesMap, chr)
+		delete(charsMap, code)
+
+		buildBitmaskTable()
+	}
+}
+
+// lowers given `text` for encoding, with the special case of Turkish (eg. 'I' to 'ı')
+func lowerText(text string) string {
+	return strings.ToLowerSpecial(unicode.TurkishCase, text)
+}
+
+// lowers given character for encoding, in the same way as `lowerText`
+func lowerChar(chr rune) rune {
+	return unicode.TurkishCase.ToLower(chr)
+}
+
 func charToCode(chr rune) (code Code, err error) {
+	mapsLock.RLock()
+	defer mapsLock.RUnlock()
+
 	var found bool
 	if code, found = codesMap[chr]; !found {
 		err = fmt.Errorf("no matching character in the codes map: '%c'", chr)
@@ -269,6 +1135,9 @@ func charToCode(chr rune) (code Code, err error) {
 
 // converts given morse code to a character.
 func codeToChar(code Code) (chr rune, err error) {
+	mapsLock.RLock()
+	defer mapsLock.RUnlock()
+
 	var found bool
 	if chr, found = charsMap[code]; !found {
 		err = fmt.Errorf("no matching code in the chars map: '%s'", code)
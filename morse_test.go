@@ -1,8 +1,17 @@
 package morse
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/speaker"
 )
 
 const (
@@ -35,6 +44,350 @@ func TestEncodeAndDecode(t *testing.T) {
 	}
 }
 
+func TestEncodeAndDecodePunctuations(t *testing.T) {
+	// no need to escape punctuations
+	phrase := "Hello, World."
+
+	if encoded, err := Encode(phrase); err != nil {
+		t.Errorf("failed to encode: %s", err)
+	} else {
+		if decoded, err := Decode(encoded); err != nil {
+			t.Errorf("failed to decode: %s", err)
+		} else {
+			// ignore case
+			if !strings.EqualFold(decoded, phrase) {
+				t.Errorf("encoded/decoded values do not match: %s / %s", decoded, phrase)
+			}
+		}
+	}
+
+	// every punctuation should be decoded back to its character
+	for _, chr := range ".,?'!/():\"=+-@" {
+		if encoded, err := Encode(string(chr)); err != nil {
+			t.Errorf("failed to encode '%c': %s", chr, err)
+		} else if decoded, err := Decode(encoded); err != nil {
+			t.Errorf("failed to decode '%c': %s", chr, err)
+		} else if decoded != string(chr) {
+			t.Errorf("expected '%c', but got '%s'", chr, decoded)
+		}
+	}
+
+	// no collisions in the codes map
+	if len(charsMap) != len(codesMap) {
+		t.Errorf("some codes are shared by more than one character: %d codes for %d characters", len(charsMap), len(codesMap))
+	}
+
+	// punctuations are kept when escaped
+	if escaped := Escape("Hello, World & Dog?!"); escaped != "Hello, World Dog?!" {
+		t.Errorf("unexpected escaped value: %s", escaped)
+	}
+}
+
+func TestRegisterCode(t *testing.T) {
+	defer UnregisterCode('&')
+
+	// successful registration
+	if err := RegisterCode('&', Ampersand); err != nil {
+		t.Fatalf("failed to register a code: %s", err)
+	}
+	if codes, err := Encode("a&b"); err != nil {
+		t.Errorf("failed to encode a registered character: %s", err)
+	} else if !reflect.DeepEqual(codes, []Code{A, Ampersand, B}) {
+		t.Errorf("unexpected codes: %v", codes)
+	}
+	if decoded, err := DecodeFast([]Code{A, Ampersand, B}); err != nil {
+		t.Errorf("failed to decode a registered code: %s", err)
+	} else if decoded != "a&b" {
+		t.Errorf("expected 'a&b', but got '%s'", decoded)
+	}
+	if code, _ := DefaultCodeTable().Code('&'); code != Ampersand {
+		t.Errorf("registered code should be in the default table, but got '%s'", code)
+	}
+
+	// overriding
+	alternative := Code(Dit + Dit + Dah + Dah + Dit + Dah)
+	if err := RegisterCode('&', alternative); err != nil {
+		t.Errorf("failed to override a code: %s", err)
+	}
+	if _, err := Decode([]Code{Ampersand}); err == nil {
+		t.Errorf("overridden code should not be decodable")
+	}
+	if decoded, _ := Decode([]Code{alternative}); decoded != "&" {
+		t.Errorf("expected '&', but got '%s'", decoded)
+	}
+
+	// collision
+	if err := RegisterCode('#', A); err == nil {
+		t.Errorf("should fail to register a code of another character")
+	}
+	if _, err := Encode("#"); err == nil {
+		t.Errorf("rejected character should not be encodable")
+	}
+
+	// invalid symbols
+	for _, code := range []Code{None, Code(".-"), Code(string(Dit) + " " + string(Dah))} {
+		if err := RegisterCode('#', code); err == nil {
+			t.Errorf("should fail to register an invalid code: '%s'", code)
+		}
+	}
+
+	// too long for the bitmask table
+	long := Code(strings.Repeat(string(Dah), maxBitmaskDurations+1))
+	if err := RegisterCode('#', long); err == nil {
+		t.Errorf("should fail to register a code of %d durations", maxBitmaskDurations+1)
+	}
+	longest := Code(strings.Repeat(string(Dah), maxBitmaskDurations))
+	if err := RegisterCode('#', longest); err != nil {
+		t.Errorf("failed to register a code of %d durations: %s", maxBitmaskDurations, err)
+	} else {
+		decoded, _ := Decode([]Code{longest})
+		if fast, err := DecodeFast([]Code{longest}); err != nil || fast != decoded || decoded != "#" {
+			t.Errorf("expected '#' with both Decode and DecodeFast, but got '%s' and '%s' (%v)", decoded, fast, err)
+		}
+		UnregisterCode('#')
+	}
+
+	// unregistration
+	UnregisterCode('&')
+	if _, err := Encode("&"); err == nil {
+		t.Errorf("unregistered character should not be encodable")
+	}
+	if _, err := Decode([]Code{alternative}); err == nil {
+		t.Errorf("unregistered code should not be decodable")
+	}
+	UnregisterCode('&') // no-op
+}
+
+func TestMismatchedMaps(t *testing.T) {
+	// a code only in the codes map, as if the maps got out of sync
+	mismatched := Code(Dit + Dit + Dit + Dit + Dit + Dit + Dit + Dit)
+
+	mapsLock.Lock()
+	codesMap['#'] = mismatched
+	mapsLock.Unlock()
+	defer func() {
+		mapsLock.Lock()
+		delete(codesMap, '#')
+		mapsLock.Unlock()
+	}()
+
+	codes, err := Encode("a#b")
+	if err != nil {
+		t.Fatalf("failed to encode: %s", err)
+	}
+	if !reflect.DeepEqual(codes, []Code{A, mismatched, B}) {
+		t.Errorf("unexpected codes: %v", codes)
+	}
+
+	// decoding fails in the middle, instead of silently returning "ab"
+	if decoded, err := Decode(codes); err == nil {
+		t.Errorf("should fail to decode a mismatched code, but got '%s'", decoded)
+	} else if decoded != "" {
+		t.Errorf("should not return a partial result, but got '%s'", decoded)
+	}
+}
+
+func TestRegisterCodeConcurrently(t *testing.T) {
+	defer UnregisterCode('#')
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = RegisterCode('#', Code(Dah+Dah+Dit+Dit+Dah+Dah))
+				UnregisterCode('#')
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := Encode("sos"); err != nil {
+					t.Errorf("failed to encode: %s", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestEncodeWith(t *testing.T) {
+	if codes, err := EncodeWith("t 10", EncodeOptions{Zero: ZeroLong}); err != nil {
+		t.Errorf("failed to encode: %s", err)
+	} else if !reflect.DeepEqual(codes, []Code{T, Space, One, Zero}) {
+		t.Errorf("expected the long zero, but got %v", codes)
+	}
+
+	// the default is the long zero
+	if codes, err := EncodeWith("10", EncodeOptions{}); err != nil {
+		t.Errorf("failed to encode: %s", err)
+	} else if !reflect.DeepEqual(codes, []Code{One, Zero}) {
+		t.Errorf("expected the long zero, but got %v", codes)
+	}
+
+	// short zero collides with 't'
+	if codes, err := EncodeWith("t 10", EncodeOptions{Zero: ZeroShort}); err != nil {
+		t.Errorf("failed to encode: %s", err)
+	} else if !reflect.DeepEqual(codes, []Code{T, Space, One, T}) {
+		t.Errorf("expected the short zero, but got %v", codes)
+	} else if decoded, _ := Decode(codes); decoded != "t 1t" {
+		t.Errorf("short zero should be decoded as 't', but got '%s'", decoded)
+	}
+
+	if _, err := EncodeWith("10~", EncodeOptions{Zero: ZeroShort}); err == nil {
+		t.Errorf("should fail to encode non-encodable characters")
+	}
+}
+
+func TestDecodeCutNumbers(t *testing.T) {
+	for _, test := range []struct {
+		text     string
+		policy   CutNumberContext
+		expected string
+	}{
+		{"5tt qth at 1t", CutNumberNone, "5tt qth at 1t"},
+		{"5tt qth at 1t", CutNumberNeighbors, "500 qth at 10"},
+		{"5tt qth at 1t", CutNumberNumeric, "500 qth at 10"},
+		{"t5t test tt", CutNumberNeighbors, "050 test tt"},
+		{"t5t test tt", CutNumberNumeric, "050 test tt"},
+		{"ut1 14tn", CutNumberNeighbors, "u01 140n"},
+		{"ut1 14tn", CutNumberNumeric, "ut1 14tn"},
+	} {
+		codes, _ := EncodeWith(test.text, EncodeOptions{Zero: ZeroShort})
+		if decoded, err := DecodeCutNumbers(codes, test.policy); err != nil {
+			t.Errorf("failed to decode '%s': %s", test.text, err)
+		} else if decoded != test.expected {
+			t.Errorf("expected '%s' from '%s' with policy %d, but got '%s'", test.expected, test.text, test.policy, decoded)
+		}
+	}
+
+	// cut zeros are resolved by context
+	codes, _ := EncodeWith("rst 590 at 0900", EncodeOptions{Zero: ZeroShort})
+	if decoded, _ := Decode(codes); decoded != "rst 59t at t9tt" {
+		t.Errorf("expected ambiguous cut zeros, but got '%s'", decoded)
+	}
+	if decoded, err := DecodeCutNumbers(codes, CutNumberNeighbors); err != nil || decoded != "rst 590 at 0900" {
+		t.Errorf("expected cut zeros resolved, but got '%s' (%v)", decoded, err)
+	}
+
+	if _, err := DecodeCutNumbers(codes, CutNumberContext(-1)); err == nil {
+		t.Errorf("should fail with an unknown policy")
+	}
+	if _, err := DecodeCutNumbers([]Code{Code("abc")}, CutNumberNeighbors); err == nil {
+		t.Errorf("should fail with invalid codes")
+	}
+}
+
+func TestAccentedLetters(t *testing.T) {
+	if encodable, err := Encodable("café"); !encodable {
+		t.Errorf("'café' should be encodable: %s", err)
+	}
+
+	phrase := "Ça über déjà señor schön läuft"
+	codes, err := Encode(phrase)
+	if err != nil {
+		t.Fatalf("failed to encode: %s", err)
+	}
+	if codes[0] != CCedilla || codes[3] != UDiaeresis {
+		t.Errorf("unexpected codes for accented letters: %v", codes)
+	}
+	if decoded, err := Decode(codes); err != nil {
+		t.Errorf("failed to decode: %s", err)
+	} else if decoded != "ça über déjà señor schön läuft" {
+		t.Errorf("encoded/decoded values do not match: %s", decoded)
+	}
+	if escaped := Escape(phrase); escaped != phrase {
+		t.Errorf("accented letters should not be escaped: %s", escaped)
+	}
+
+	// letters without their own codes
+	if _, err := Encode("crème brûlée"); err == nil {
+		t.Errorf("should fail to encode letters without their own codes")
+	}
+	if codes, err := EncodeWith("Crème brûlée", EncodeOptions{FoldAccents: true}); err != nil {
+		t.Errorf("failed to encode with folding: %s", err)
+	} else if decoded, _ := Decode(codes); decoded != "creme brulée" {
+		t.Errorf("only letters without their own codes should be folded, but got '%s'", decoded)
+	}
+}
+
+func TestEncodeLenient(t *testing.T) {
+	if codes := EncodeLenient("a#b"); !reflect.DeepEqual(codes, []Code{A, B}) {
+		t.Errorf("expected codes of 'a' and 'b', but got %v", codes)
+	}
+
+	// spaces are preserved
+	if codes := EncodeLenient(" s~o  s "); !reflect.DeepEqual(codes, []Code{Space, S, O, Space, Space, S, Space}) {
+		t.Errorf("spaces should be preserved, but got %v", codes)
+	}
+
+	if codes := EncodeLenient("~#*"); len(codes) != 0 {
+		t.Errorf("expected no codes, but got %v", codes)
+	}
+}
+
+func TestEncodeReport(t *testing.T) {
+	codes, invalid, err := EncodeReport("so#s~ he*lp #")
+
+	if !reflect.DeepEqual(invalid, []rune{'#', '~', '*', '#'}) {
+		t.Errorf("expected all non-encodable characters, but got %q", invalid)
+	}
+	if err == nil {
+		t.Errorf("should return an error for non-encodable characters")
+	} else {
+		for _, position := range []string{"'#' at 2", "'~' at 4", "'*' at 8", "'#' at 12"} {
+			if !strings.Contains(err.Error(), position) {
+				t.Errorf("error should include %s: %s", position, err)
+			}
+		}
+	}
+
+	// the rest is encoded
+	if expected, _ := Encode("sos help "); !reflect.DeepEqual(codes, expected) {
+		t.Errorf("expected %v, but got %v", expected, codes)
+	}
+
+	// encodable text
+	if codes, invalid, err := EncodeReport("SOS"); err != nil || len(invalid) != 0 || !reflect.DeepEqual(codes, []Code{S, O, S}) {
+		t.Errorf("unexpected result for an encodable text: %v / %q / %v", codes, invalid, err)
+	}
+}
+
+func TestEncodeCallback(t *testing.T) {
+	escapedPhrase := Escape(testPhrase)
+
+	codes := []Code{}
+	chars := []rune{}
+	if err := EncodeCallback(escapedPhrase, func(i int, chr rune, code Code) {
+		if i != len(codes) {
+			t.Errorf("expected index %d, but got %d", len(codes), i)
+		}
+		codes = append(codes, code)
+		chars = append(chars, chr)
+	}); err != nil {
+		t.Errorf("failed to encode: %s", err)
+	}
+
+	if encoded, _ := Encode(escapedPhrase); !reflect.DeepEqual(codes, encoded) {
+		t.Errorf("encoded values do not match: %v / %v", codes, encoded)
+	}
+	if string(chars) != strings.ToLower(escapedPhrase) {
+		t.Errorf("encoded characters do not match: %s / %s", string(chars), escapedPhrase)
+	}
+
+	// not encodable
+	called := false
+	if err := EncodeCallback(testPhrase, func(i int, chr rune, code Code) {
+		called = true
+	}); err == nil {
+		t.Errorf("should fail to encode: %s", testPhrase)
+	}
+	if called {
+		t.Errorf("callback should not be called for non-encodable text")
+	}
+}
+
 func BenchmarkEncode(b *testing.B) {
 	escapedPhrase := Escape(testPhrase)
 
@@ -52,3 +405,627 @@ func BenchmarkDecode(b *testing.B) {
 		}
 	}
 }
+
+func TestBeepOptions(t *testing.T) {
+	if err := DefaultBeepOptions().validate(); err != nil {
+		t.Errorf("default options should be valid: %s", err)
+	}
+
+	// invalid options should fail before playing
+	for _, opts := range []BeepOptions{
+		{Hz: 0, WPM: 10, Volume: 1},
+		{Hz: 800, WPM: 0, Volume: 1},
+		{Hz: 800, WPM: -5, Volume: 1},
+		{Hz: 800, WPM: 10, Volume: 1.5},
+		{Hz: 800, WPM: 10, Volume: -0.1},
+		{Hz: 800, WPM: 10, Volume: 1, CharWPM: -1},
+		{Hz: 800, WPM: 10, Volume: 1, Ramp: -time.Millisecond},
+		{Hz: 800, WPM: 10, Volume: 1, CharWPM: 5, EffectiveWPM: 18},
+		{Hz: 800, WPM: 10, Volume: 1, Rounding: RoundingMode(-1)},
+		{Hz: 800, WPM: 10, Volume: 1, BitDepth: 12},
+	} {
+		if err := BeepWith([]Code{E}, opts); err == nil {
+			t.Errorf("should fail with invalid options: %+v", opts)
+		}
+	}
+}
+
+func TestFarnsworthTiming(t *testing.T) {
+	uniform := DefaultBeepOptions()
+	uniform.WPM = 18
+
+	// same speeds should match the uniform timing exactly
+	same := uniform
+	same.CharWPM, same.EffectiveWPM = 18, 18
+
+	unit, charGap, wordGap := uniform.timings()
+	if unit != unitDuration(18) || charGap != 3*unit || wordGap != 7*unit {
+		t.Errorf("unexpected uniform timings: %s / %s / %s", unit, charGap, wordGap)
+	}
+	if u, c, w := same.timings(); u != unit || c != charGap || w != wordGap {
+		t.Errorf("timings with same speeds should be uniform: %s / %s / %s", u, c, w)
+	}
+
+	// dits stay short, while gaps grow
+	farnsworth := uniform
+	farnsworth.CharWPM, farnsworth.EffectiveWPM = 18, 5
+
+	u, c, w := farnsworth.timings()
+	if u != unit {
+		t.Errorf("unit should stay %s, but got %s", unit, u)
+	}
+	if c <= charGap || w <= wordGap {
+		t.Errorf("gaps should grow: %s / %s", c, w)
+	}
+	if w != c*7/3 {
+		t.Errorf("word gap should be 7/3 times the character gap: %s / %s", w, c)
+	}
+
+	// "PARIS " should take a minute at the effective speed
+	paris := 10*u + 4*3*u + 9*u + 4*c + w // 10 dits, 4 dahs, 9 intra-character gaps
+	if minute := paris * 5; minute < 59900*time.Millisecond || minute > 60100*time.Millisecond {
+		t.Errorf("expected 5 words in a minute, but took %s", minute)
+	}
+
+	// streams should be longer with Farnsworth timing
+	codes, _ := Encode("hi hi")
+	length := func(opts BeepOptions) (n int) {
+		streamer, _ := streamCodes(codes, 44100, opts)
+		buf := make([][2]float64, 512)
+		for {
+			m, ok := streamer.Stream(buf)
+			if !ok {
+				return n
+			}
+			n += m
+		}
+	}
+	if l, f := length(uniform), length(farnsworth); l != length(same) || f <= l {
+		t.Errorf("unexpected lengths of streams: %d (uniform) / %d (farnsworth)", l, f)
+	}
+}
+
+func TestSamples(t *testing.T) {
+	codes, _ := Encode("hi there")
+
+	opts := DefaultBeepOptions()
+	opts.SampleRate = 8000
+	opts.Volume = 0.5
+
+	samples := Samples(codes, opts)
+
+	// total duration times the sample rate
+	var total time.Duration
+	for _, signal := range Timeline(codes, opts.WPM) {
+		total += signal.Duration
+	}
+	if expected := int(math.Round(total.Seconds() * 8000)); len(samples) != expected {
+		t.Errorf("expected %d samples, but got %d", expected, len(samples))
+	}
+
+	// amplitude from the options, and silences for gaps
+	peak, silent := 0.0, 0
+	for _, sample := range samples {
+		peak = max(peak, sample[0], -sample[0])
+		if sample[0] == 0 && sample[1] == 0 {
+			silent++
+		}
+	}
+	if peak > 0.5 || peak < 0.45 { // 10 samples per cycle may miss the exact peak
+		t.Errorf("expected peak amplitude of 0.5, but got %f", peak)
+	}
+	if silent == 0 {
+		t.Errorf("expected silences for gaps")
+	}
+
+	// frequency from the options
+	first := make([]float64, beep.SampleRate(opts.SampleRate).N(unitDuration(float64(opts.WPM))))
+	for i := range first {
+		first[i] = samples[i][0]
+	}
+	if detected := DetectToneHz(first, opts.SampleRate); math.Abs(detected-float64(opts.Hz)) > 10 {
+		t.Errorf("expected a tone of %d Hz, but got %f", opts.Hz, detected)
+	}
+
+	if samples := Samples([]Code{Code("abc")}, opts); samples != nil {
+		t.Errorf("expected nil for invalid codes, but got %d samples", len(samples))
+	}
+	if samples := Samples(codes, BeepOptions{}); samples != nil {
+		t.Errorf("expected nil for invalid options, but got %d samples", len(samples))
+	}
+}
+
+func TestRounding(t *testing.T) {
+	codes, _ := Encode(strings.Repeat("paris ", 20))
+
+	// at 13 WPM, a unit is about 4070.77 samples, which is not a whole number of samples
+	opts := DefaultBeepOptions()
+	opts.WPM = 13
+
+	var total time.Duration
+	for _, signal := range TimelineWith(codes, opts) {
+		total += signal.Duration
+	}
+	exact := total.Seconds() * float64(opts.SampleRate)
+
+	errors := map[RoundingMode]float64{}
+	for _, mode := range []RoundingMode{RoundTruncate, RoundNearest, RoundNearestEven} {
+		opts.Rounding = mode
+		errors[mode] = float64(len(Samples(codes, opts))) - exact
+	}
+
+	// truncation is less than a sample behind the exact time, and rounding is within a half of a sample
+	if errors[RoundTruncate] > 0 || errors[RoundTruncate] <= -1 {
+		t.Errorf("expected truncation less than a sample behind, but got an error of %f samples", errors[RoundTruncate])
+	}
+	for _, mode := range []RoundingMode{RoundNearest, RoundNearestEven} {
+		if math.Abs(errors[mode]) > 0.5 {
+			t.Errorf("expected rounding (%d) within a half of a sample, but got an error of %f samples", mode, errors[mode])
+		}
+		if math.Abs(errors[mode]) >= math.Abs(errors[RoundTruncate]) {
+			t.Errorf("expected less error with rounding (%d) than truncation: %f / %f samples", mode, errors[mode], errors[RoundTruncate])
+		}
+	}
+}
+
+func TestRamp(t *testing.T) {
+	codes := []Code{T}
+
+	opts := DefaultBeepOptions()
+	ramped := Samples(codes, opts)
+
+	opts.Ramp = 0
+	abrupt := Samples(codes, opts)
+
+	if len(ramped) != len(abrupt) {
+		t.Fatalf("ramps should not change the length: %d / %d", len(ramped), len(abrupt))
+	}
+
+	ramp := beep.SampleRate(opts.SampleRate).N(DefaultBeepOptions().Ramp)
+
+	// gains of the envelope, where the tone is not at a zero crossing
+	gains := func(from, to int) (gains []float64) {
+		for i := from; i < to; i++ {
+			if math.Abs(abrupt[i][0]) > 0.1 {
+				gains = append(gains, ramped[i][0]/abrupt[i][0])
+			}
+		}
+		return gains
+	}
+
+	// attack
+	attack := gains(0, ramp)
+	for i, gain := range attack {
+		if gain >= 1 {
+			t.Errorf("sample in the attack should be below full amplitude, but got a gain of %f", gain)
+		}
+		if i > 0 && gain < attack[i-1] {
+			t.Errorf("attack should ramp up monotonically: %f -> %f", attack[i-1], gain)
+		}
+	}
+
+	// release
+	release := gains(len(ramped)-ramp, len(ramped))
+	for i, gain := range release {
+		if gain >= 1 {
+			t.Errorf("sample in the release should be below full amplitude, but got a gain of %f", gain)
+		}
+		if i > 0 && gain > release[i-1] {
+			t.Errorf("release should ramp down monotonically: %f -> %f", release[i-1], gain)
+		}
+	}
+
+	// full amplitude between the ramps
+	for _, gain := range gains(ramp, len(ramped)-ramp) {
+		if math.Abs(gain-1) > 1e-9 {
+			t.Errorf("sample between ramps should be at full amplitude, but got a gain of %f", gain)
+			break
+		}
+	}
+
+	if len(attack) == 0 || len(release) == 0 {
+		t.Errorf("no samples were checked in ramps")
+	}
+
+	// a tone shorter than its ramps
+	opts.Ramp = time.Second
+	if samples := Samples(codes, opts); len(samples) != len(abrupt) {
+		t.Errorf("long ramps should not change the length: %d / %d", len(samples), len(abrupt))
+	}
+}
+
+func TestMessageFade(t *testing.T) {
+	codes, _ := Encode(strings.Repeat("e", 20))
+
+	opts := DefaultBeepOptions()
+	opts.MessageFadeIn, opts.MessageFadeOut = time.Second, time.Second
+	faded := Samples(codes, opts)
+
+	if abrupt := Samples(codes, DefaultBeepOptions()); len(faded) != len(abrupt) {
+		t.Fatalf("fades should not change the length: %d / %d", len(faded), len(abrupt))
+	}
+
+	// peak amplitude of given tenth of the samples
+	peak := func(tenth int) (peak float64) {
+		for _, sample := range faded[len(faded)*tenth/10 : len(faded)*(tenth+1)/10] {
+			peak = max(peak, math.Abs(sample[0]))
+		}
+		return peak
+	}
+
+	first, middle, last := peak(0), peak(5), peak(9)
+	if first >= middle*0.9 {
+		t.Errorf("beginning of the message should be faded in: %f / %f", first, middle)
+	}
+	if last >= middle*0.9 {
+		t.Errorf("end of the message should be faded out: %f / %f", last, middle)
+	}
+
+	opts.MessageFadeIn = -time.Second
+	if err := opts.validate(); err == nil {
+		t.Errorf("should fail with a negative fade")
+	}
+}
+
+func TestBeeperVolume(t *testing.T) {
+	samples := make([][2]float64, 441)
+	beeper(800, 0.25, 44100).Stream(samples)
+
+	peak := 0.0
+	for _, sample := range samples {
+		peak = max(peak, sample[0], -sample[0])
+	}
+	if peak > 0.25 || peak < 0.24 {
+		t.Errorf("expected peak amplitude of 0.25, but got %f", peak)
+	}
+}
+
+func TestBeeperPhaseContinuity(t *testing.T) {
+	const hz, volume, sr = 800, 1.0, beep.SampleRate(44100)
+
+	// maximum difference between adjacent samples of a continuous sine
+	maxStep := volume*2*math.Pi*hz/float64(sr) + 1e-9
+
+	// stream in buffers of odd sizes, so boundaries fall on arbitrary phases
+	b := beeper(hz, volume, sr)
+	samples := [][2]float64{}
+	for _, size := range []int{1, 7, 100, 33, 512, 3} {
+		buf := make([][2]float64, size)
+		b.Stream(buf)
+		samples = append(samples, buf...)
+	}
+	if samples[0][0] != 0 {
+		t.Errorf("tone should start from a zero crossing, but got %f", samples[0][0])
+	}
+	for i := 1; i < len(samples); i++ {
+		if diff := math.Abs(samples[i][0] - samples[i-1][0]); diff > maxStep {
+			t.Errorf("discontinuity of %f between samples %d and %d", diff, i-1, i)
+		}
+	}
+
+	// reset phase
+	b.(*tone).reset()
+	buf := make([][2]float64, 1)
+	b.Stream(buf)
+	if buf[0][0] != 0 {
+		t.Errorf("tone should restart from a zero crossing, but got %f", buf[0][0])
+	}
+
+	// silence and tone segments of a stream should join without jumps
+	codes, _ := Encode("ee")
+	opts := DefaultBeepOptions()
+	opts.Hz = hz
+	streamer, err := streamCodes(codes, sr, opts)
+	if err != nil {
+		t.Fatalf("failed to stream codes: %s", err)
+	}
+	segment := sr.N(unitDuration(float64(opts.WPM)))
+	all := make([][2]float64, segment*5)
+	for filled := 0; filled < len(all); {
+		n, ok := streamer.Stream(all[filled:min(filled+100, len(all))])
+		if !ok {
+			break
+		}
+		filled += n
+	}
+	if start := all[segment*4][0]; start != 0 {
+		t.Errorf("tone after a gap should start from a zero crossing, but got %f", start)
+	}
+	for i := segment*4 + 1; i < len(all); i++ {
+		if diff := math.Abs(all[i][0] - all[i-1][0]); diff > maxStep {
+			t.Errorf("discontinuity of %f in the second tone at sample %d", diff, i)
+			break
+		}
+	}
+}
+
+func TestBeepContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	codes, _ := Encode("a long message which would take a while to play")
+
+	started := time.Now()
+	if err := BeepContext(ctx, codes); err != context.Canceled {
+		t.Errorf("expected '%s', but got '%v'", context.Canceled, err)
+	}
+	if elapsed := time.Since(started); elapsed > 100*time.Millisecond {
+		t.Errorf("should return promptly when canceled, but took %s", elapsed)
+	}
+}
+
+// replaces the speaker with a fake one which drains played streamers into the returned samples,
+// and returns a function for restoring it
+func fakeSpeaker(init func(sampleRate beep.SampleRate, bufferSize int) error) (played *[][2]float64, restore func()) {
+	played = &[][2]float64{}
+
+	speakerSampleRate, speakerBufferSize = 0, 0
+	speakerInit = init
+	speakerPlay = func(s ...beep.Streamer) {
+		buf := make([][2]float64, 512)
+		for _, streamer := range s {
+			for {
+				n, ok := streamer.Stream(buf)
+				*played = append(*played, buf[:n]...)
+				if !ok {
+					break
+				}
+			}
+		}
+	}
+	speakerClear = func() {}
+
+	return played, func() {
+		speakerInit, speakerPlay, speakerClear = speaker.Init, speaker.Play, speaker.Clear
+		speakerSampleRate, speakerBufferSize = 0, 0
+	}
+}
+
+func TestBeepWith(t *testing.T) {
+	played, restore := fakeSpeaker(func(sampleRate beep.SampleRate, bufferSize int) error { return nil })
+	defer restore()
+
+	codes, _ := Encode("sos e")
+
+	// played in the same timing as the rendered samples
+	for _, opts := range []BeepOptions{
+		DefaultBeepOptions(),
+		{Hz: 600, WPM: 18, Volume: 0.5, SampleRate: 8000, CharWPM: 18, EffectiveWPM: 10},
+	} {
+		*played = [][2]float64{}
+		if err := BeepWith(codes, opts); err != nil {
+			t.Fatalf("failed to beep: %s", err)
+		}
+		if expected := Samples(codes, opts); !reflect.DeepEqual(*played, expected) {
+			t.Errorf("expected %d samples same as the rendered ones, but played %d", len(expected), len(*played))
+		}
+	}
+
+	if err := BeepWith([]Code{Code("abc")}, DefaultBeepOptions()); err == nil {
+		t.Errorf("should fail with invalid codes")
+	}
+}
+
+func TestBeepChar(t *testing.T) {
+	played, restore := fakeSpeaker(func(sampleRate beep.SampleRate, bufferSize int) error { return nil })
+	defer restore()
+
+	opts := DefaultBeepOptions()
+	opts.Ramp = 0
+
+	for chr, expected := range map[rune]Code{'A': A, 'q': Q, '5': Five} {
+		*played = [][2]float64{}
+		if err := BeepChar(chr, opts); err != nil {
+			t.Fatalf("failed to beep '%c': %s", chr, err)
+		}
+
+		samples := *played
+		stream := beep.StreamerFunc(func(buf [][2]float64) (n int, ok bool) {
+			n = copy(buf, samples)
+			samples = samples[n:]
+			return n, n > 0
+		})
+		if codes := codesFromStream(t, stream, opts.sampleRate(), opts); !reflect.DeepEqual(codes, []Code{expected}) {
+			t.Errorf("expected %v for '%c', but played %v", []Code{expected}, chr, codes)
+		}
+	}
+
+	if err := BeepChar('~', opts); err == nil {
+		t.Errorf("should fail with a non-encodable character")
+	}
+
+	// capital I's are lowered as `Encode` does
+	for _, chr := range []rune{'I', 'İ'} {
+		*played = [][2]float64{}
+		codes, errEncode := Encode(string(chr))
+		if err := BeepChar(chr, opts); (err == nil) != (errEncode == nil) {
+			t.Errorf("expected '%c' to fail as `Encode` does (%v), but got %v", chr, errEncode, err)
+		} else if err == nil && !reflect.DeepEqual(*played, Samples(codes, opts)) {
+			t.Errorf("expected '%c' played as %v, but played %d samples", chr, codes, len(*played))
+		}
+	}
+	if err := BeepChar('I', opts); err == nil {
+		t.Errorf("should fail with 'I', which is lowered to the dotless 'ı'")
+	}
+}
+
+func TestBeepRepeat(t *testing.T) {
+	played, restore := fakeSpeaker(func(sampleRate beep.SampleRate, bufferSize int) error { return nil })
+	defer restore()
+
+	codes, _ := Encode("cq")
+	sk, _ := EncodeProsign("SK")
+
+	opts := DefaultBeepOptions()
+	opts.SampleRate = 8000
+
+	markerOpts := opts
+	markerOpts.Hz = 1200
+
+	_, _, wordGap := opts.timings()
+	gap := make([][2]float64, opts.samples(opts.sampleRate(), wordGap))
+	message, marker := Samples(codes, opts), Samples([]Code{sk}, markerOpts)
+
+	for _, test := range []struct {
+		marker   RepeatMarker
+		expected [][][2]float64
+	}{
+		// marker between repetitions, but not before the first one
+		{RepeatMarker{Codes: []Code{sk}, Hz: 1200}, [][][2]float64{message, gap, marker, gap, message, gap, marker, gap, message}},
+
+		// no marker
+		{RepeatMarker{}, [][][2]float64{message, gap, message, gap, message}},
+	} {
+		*played = [][2]float64{}
+		if err := BeepRepeat(context.Background(), codes, 3, test.marker, opts); err != nil {
+			t.Fatalf("failed to beep: %s", err)
+		}
+		expected := [][2]float64{}
+		for _, samples := range test.expected {
+			expected = append(expected, samples...)
+		}
+		if !reflect.DeepEqual(*played, expected) {
+			t.Errorf("expected %d samples repeated with marker %+v, but played %d samples", len(expected), test.marker, len(*played))
+		}
+	}
+
+	if err := BeepRepeat(context.Background(), codes, 0, RepeatMarker{}, opts); err == nil {
+		t.Errorf("should fail with no repetitions")
+	}
+	if err := BeepRepeat(context.Background(), codes, 2, RepeatMarker{Codes: []Code{Code("abc")}}, opts); err == nil {
+		t.Errorf("should fail with an invalid marker")
+	}
+	if err := BeepRepeat(context.Background(), codes, 2, RepeatMarker{Codes: []Code{sk}, Hz: -1}, opts); err == nil {
+		t.Errorf("should fail with a negative frequency of the marker")
+	}
+}
+
+func TestSpeakerInitializedOnce(t *testing.T) {
+	// replace the init function, and restore it after the test
+	initialized := 0
+	_, restore := fakeSpeaker(func(sampleRate beep.SampleRate, bufferSize int) error {
+		initialized++
+		return nil
+	})
+	defer restore()
+
+	for i := 0; i < 3; i++ {
+		Beep([]Code{})
+	}
+	if initialized != 1 {
+		t.Errorf("speaker should be initialized only once, but was initialized %d times", initialized)
+	}
+
+	// re-initialized only when the sample rate changes
+	initSpeaker(beep.SampleRate(22050), defaultBufferDuration)
+	initSpeaker(beep.SampleRate(22050), defaultBufferDuration)
+	if initialized != 2 {
+		t.Errorf("speaker should be initialized again for a new sample rate, but was initialized %d times", initialized)
+	}
+
+	// re-initialized when the buffer size changes
+	initSpeaker(beep.SampleRate(22050), time.Second/10)
+	if initialized != 3 {
+		t.Errorf("speaker should be initialized again for a new buffer size, but was initialized %d times", initialized)
+	}
+}
+
+func TestBufferDuration(t *testing.T) {
+	bufferSizes := []int{}
+	_, restore := fakeSpeaker(func(sampleRate beep.SampleRate, bufferSize int) error {
+		bufferSizes = append(bufferSizes, bufferSize)
+		return nil
+	})
+	defer restore()
+
+	opts := DefaultBeepOptions()
+	opts.SampleRate = 8000
+	if err := BeepWith([]Code{E}, opts); err != nil {
+		t.Fatalf("failed to beep: %s", err)
+	}
+	opts.BufferDuration = 50 * time.Millisecond
+	if err := BeepWith([]Code{E}, opts); err != nil {
+		t.Fatalf("failed to beep: %s", err)
+	}
+
+	// 10 ms by default, then 50 ms
+	if expected := []int{80, 400}; !reflect.DeepEqual(bufferSizes, expected) {
+		t.Errorf("expected buffer sizes %v, but got %v", expected, bufferSizes)
+	}
+
+	opts.BufferDuration = -time.Millisecond
+	if err := BeepWith([]Code{E}, opts); err == nil {
+		t.Errorf("should fail with a negative buffer duration")
+	}
+}
+
+func TestCodeMethods(t *testing.T) {
+	for _, c := range []struct {
+		code       Code
+		str        string
+		dits, dahs int
+		valid      bool
+	}{
+		{None, "", 0, 0, false},
+		{Space, " ", 0, 0, false},
+		{A, ".-", 1, 1, true},
+		{Zero, "-----", 0, 5, true},
+		{Code("•x−"), ".x-", 1, 1, false},
+	} {
+		if str := c.code.String(); str != c.str {
+			t.Errorf("expected '%s' for '%s', but got '%s'", c.str, string(c.code), str)
+		}
+		if dits, dahs := c.code.Symbols(); dits != c.dits || dahs != c.dahs {
+			t.Errorf("expected %d dits and %d dahs for '%s', but got %d and %d", c.dits, c.dahs, string(c.code), dits, dahs)
+		}
+		if valid := c.code.Valid(); valid != c.valid {
+			t.Errorf("expected validity %t for '%s', but got %t", c.valid, string(c.code), valid)
+		}
+	}
+
+	// formatted with the method
+	if str := fmt.Sprintf("%v", []Code{S, O, S}); str != "[... --- ...]" {
+		t.Errorf("expected '[... --- ...]', but got '%s'", str)
+	}
+}
+
+func TestSpaces(t *testing.T) {
+	// single and double spaces are encoded in the same way, with or without escaping
+	for _, text := range []string{"a b", "a  b", "a \t b"} {
+		codes, err := Encode(text)
+		if err != nil {
+			t.Errorf("failed to encode '%s': %s", text, err)
+			continue
+		}
+		if expected := []Code{A, Space, B}; !reflect.DeepEqual(codes, expected) {
+			t.Errorf("expected %v for '%s', but got %v", expected, text, codes)
+		}
+
+		if escaped, err := Encode(Escape(text)); err != nil || !reflect.DeepEqual(escaped, codes) {
+			t.Errorf("expected %v for escaped '%s', but got %v (%v)", codes, text, escaped, err)
+		}
+
+		if decoded, err := Decode(codes); err != nil || decoded != "a b" {
+			t.Errorf("expected 'a b' for '%s', but got '%s' (%v)", text, decoded, err)
+		}
+	}
+}
+
+func TestDecodeWords(t *testing.T) {
+	for _, c := range []struct {
+		codes    []Code
+		expected string
+	}{
+		{[]Code{S, O, S}, "sos"},
+		{[]Code{S, O, S, Space, H, I}, "sos hi"},
+		{[]Code{Space, S, O, S, Space, Space, Space, H, I, Space}, "sos hi"},
+		{[]Code{Code("..."), Code("---"), Code("..."), Code("  "), Code("...."), Code("..")}, "sos hi"},
+		{[]Code{S, Code("•••••••"), Space, Space, I}, "s i"}, // non-decodable code is skipped
+		{[]Code{S, Space, Code("•••••••"), Space, I}, "s i"},
+		{[]Code{}, ""},
+	} {
+		if decoded := DecodeWords(c.codes); decoded != c.expected {
+			t.Errorf("expected '%s' for %v, but got '%s'", c.expected, c.codes, decoded)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package morse
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// returns given `random`, or a new one seeded with the current time when it is nil.
+func randomOrNew(random *rand.Rand) *rand.Rand {
+	if random == nil {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return random
+}
+
+// BandNoise returns an endless stream of noise limited to a band of `bandwidthHz` around `centerHz`,
+// which can be mixed under messages for simulating a busy band.
+//
+// White noise is generated from `random` (a time-seeded one is used when nil),
+// so the same seed reproduces the same noise, and filtered with a band-pass biquad filter.
+//
+// Will return an error when `sampleRate` or `bandwidthHz` is not positive,
+// or `centerHz` is not between 0 and the Nyquist frequency (half of `sampleRate`).
+func BandNoise(random *rand.Rand, sampleRate int, centerHz, bandwidthHz float64) (noise beep.Streamer, err error) {
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("sample rate should be positive: %d", sampleRate)
+	}
+	if centerHz <= 0 || centerHz >= float64(sampleRate)/2 {
+		return nil, fmt.Errorf("center frequency should be between 0 and %d Hz: %f", sampleRate/2, centerHz)
+	}
+	if bandwidthHz <= 0 {
+		return nil, fmt.Errorf("bandwidth should be positive: %f", bandwidthHz)
+	}
+
+	random = randomOrNew(random)
+
+	// coefficients of the band-pass filter (constant 0 dB peak gain)
+	w0 := 2 * math.Pi * centerHz / float64(sampleRate)
+	alpha := math.Sin(w0) / (2 * (centerHz / bandwidthHz))
+
+	a0 := 1 + alpha
+	b0, b2 := alpha/a0, -alpha/a0
+	a1, a2 := -2*math.Cos(w0)/a0, (1-alpha)/a0
+
+	// states of the filter
+	var x1, x2, y1, y2 float64
+
+	return beep.StreamerFunc(func(samples [][2]float64) (n int, ok bool) {
+		for i := range samples {
+			x0 := random.Float64()*2 - 1
+			y0 := b0*x0 + b2*x2 - a1*y1 - a2*y2
+
+			x2, x1 = x1, x0
+			y2, y1 = y1, y0
+
+			samples[i][0] = y0
+			samples[i][1] = y0
+		}
+		return len(samples), true
+	}), nil
+}
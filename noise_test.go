@@ -0,0 +1,92 @@
+package morse
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// power of given frequency in samples (Goertzel algorithm)
+func goertzelPower(samples []float64, hz float64, sampleRate int) float64 {
+	coeff := 2 * math.Cos(2*math.Pi*hz/float64(sampleRate))
+
+	var s1, s2 float64
+	for _, sample := range samples {
+		s1, s2 = sample+coeff*s1-s2, s1
+	}
+
+	return s1*s1 + s2*s2 - coeff*s1*s2
+}
+
+func TestBandNoise(t *testing.T) {
+	const (
+		sampleRate  = 8000
+		centerHz    = 700.0
+		bandwidthHz = 200.0
+	)
+
+	noise, err := BandNoise(nil, sampleRate, centerHz, bandwidthHz)
+	if err != nil {
+		t.Fatalf("failed to create noise: %s", err)
+	}
+
+	buf := make([][2]float64, sampleRate)
+	if n, ok := noise.Stream(buf); n != len(buf) || !ok {
+		t.Fatalf("failed to stream noise: %d, %t", n, ok)
+	}
+
+	samples := make([]float64, len(buf))
+	for i := range buf {
+		samples[i] = buf[i][0]
+	}
+
+	// average powers in and out of the band
+	average := func(from, to float64) float64 {
+		sum, count := 0.0, 0
+		for hz := from; hz <= to; hz += 10 {
+			sum += goertzelPower(samples, hz, sampleRate)
+			count++
+		}
+		return sum / float64(count)
+	}
+
+	inBand := average(centerHz-bandwidthHz/2, centerHz+bandwidthHz/2)
+	below := average(100, 300)
+	above := average(1500, 3000)
+
+	if inBand < below*10 || inBand < above*10 {
+		t.Errorf("noise energy is not concentrated in the band: %.2f (in) / %.2f (below) / %.2f (above)", inBand, below, above)
+	}
+
+	// invalid arguments, which would make samples NaN
+	for _, tc := range []struct {
+		sampleRate            int
+		centerHz, bandwidthHz float64
+	}{
+		{0, centerHz, bandwidthHz},
+		{sampleRate, 0, bandwidthHz},
+		{sampleRate, sampleRate / 2, bandwidthHz},
+		{sampleRate, centerHz, 0},
+	} {
+		if _, err := BandNoise(nil, tc.sampleRate, tc.centerHz, tc.bandwidthHz); err == nil {
+			t.Errorf("should fail with invalid arguments: %+v", tc)
+		}
+	}
+}
+
+func TestBandNoiseWithSeed(t *testing.T) {
+	generate := func(seed int64) [][2]float64 {
+		buf := make([][2]float64, 1000)
+		noise, _ := BandNoise(rand.New(rand.NewSource(seed)), 8000, 700, 200)
+		noise.Stream(buf)
+		return buf
+	}
+
+	if !reflect.DeepEqual(generate(42), generate(42)) {
+		t.Errorf("noise should be identical for the same seed")
+	}
+	if reflect.DeepEqual(generate(42), generate(43)) {
+		t.Errorf("noise should differ for different seeds")
+	}
+}
@@ -0,0 +1,125 @@
+package morse
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// replacer for normalizing glyphs of durations
+var glyphReplacer = strings.NewReplacer(
+	// dits
+	".", string(Dit),
+	"·", string(Dit),
+	"∙", string(Dit),
+	"*", string(Dit),
+
+	// dahs
+	"-", string(Dah),
+	"–", string(Dah),
+	"—", string(Dah),
+	"_", string(Dah),
+)
+
+// normalizes glyphs of given `code` to `Dit`s and `Dah`s,
+// and a code of whitespaces only to `Space`.
+func normalizeCode(code Code) Code {
+	if code != None && strings.TrimFunc(string(code), unicode.IsSpace) == "" {
+		return Space
+	}
+
+	return Code(glyphReplacer.Replace(string(code)))
+}
+
+// normalizes glyphs of given `codes`,
+// and collapses redundant (leading, trailing, and consecutive) `Space`s.
+func normalizeCodes(codes []Code) (normalized []Code) {
+	normalized = []Code{}
+
+	for _, code := range codes {
+		code = normalizeCode(code)
+
+		if code == Space && (len(normalized) == 0 || normalized[len(normalized)-1] == Space) {
+			continue
+		}
+
+		normalized = append(normalized, code)
+	}
+	if len(normalized) > 0 && normalized[len(normalized)-1] == Space {
+		normalized = normalized[:len(normalized)-1]
+	}
+
+	return normalized
+}
+
+// EquivalentMessages returns whether given messages `a` and `b` are the same
+// after normalizing glyphs (like '.' and '-') and collapsing redundant `Space`s.
+func EquivalentMessages(a, b []Code) bool {
+	normalizedA, normalizedB := normalizeCodes(a), normalizeCodes(b)
+	if len(normalizedA) != len(normalizedB) {
+		return false
+	}
+
+	for i := range normalizedA {
+		if normalizedA[i] != normalizedB[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Sanitize normalizes glyphs of given `codes` and removes stray characters in them,
+// drops empty codes, and collapses redundant (leading, trailing, and consecutive) `Space`s,
+// so that the result is ready for `Decode`.
+func Sanitize(codes []Code) (sanitized []Code) {
+	cleaned := make([]Code, 0, len(codes))
+	for _, code := range codes {
+		if code = normalizeCode(code); code != Space {
+			code = Code(strings.Map(func(r rune) rune {
+				if r == ditRune || r == dahRune {
+					return r
+				}
+				return -1
+			}, string(code)))
+		}
+
+		if code != None {
+			cleaned = append(cleaned, code)
+		}
+	}
+
+	return normalizeCodes(cleaned)
+}
+
+// Fold folds characters without their own codes in given `text` to their base characters when possible,
+// by decomposing them (NFD) and stripping combining marks (eg. 'ê' to 'e', or 'ï' to 'i').
+//
+// Characters with their own codes (eg. 'é' or 'ü') are left untouched,
+// and so are the ones which cannot be folded to encodable characters.
+func Fold(text string) string {
+	var sb strings.Builder
+
+	for _, chr := range text {
+		if _, err := charToCode(unicode.TurkishCase.ToLower(chr)); err == nil {
+			sb.WriteRune(chr)
+			continue
+		}
+
+		folded := strings.Map(func(r rune) rune {
+			if unicode.Is(unicode.Mn, r) {
+				return -1
+			}
+			return r
+		}, norm.NFD.String(string(chr)))
+
+		if _, err := Encodable(folded); err == nil && folded != "" {
+			sb.WriteString(folded)
+		} else {
+			sb.WriteRune(chr)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,98 @@
+package morse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEquivalentMessages(t *testing.T) {
+	encoded, _ := Encode("sos help")
+
+	// differently-spaced encodings of the same text
+	spaced, _ := Encode("  sos   help ")
+	if !EquivalentMessages(encoded, spaced) {
+		t.Errorf("differently-spaced messages should be equivalent: %v / %v", encoded, spaced)
+	}
+
+	// different glyphs
+	ascii := []Code{"...", "---", "...", "\t", "....", ".", ".-..", ".--."}
+	if !EquivalentMessages(encoded, ascii) {
+		t.Errorf("messages with different glyphs should be equivalent: %v / %v", encoded, ascii)
+	}
+
+	// different messages
+	different, _ := Encode("sos hell")
+	if EquivalentMessages(encoded, different) {
+		t.Errorf("different messages should not be equivalent: %v / %v", encoded, different)
+	}
+	joined, _ := Encode("soshelp")
+	if EquivalentMessages(encoded, joined) {
+		t.Errorf("messages without a word gap should not be equivalent: %v / %v", encoded, joined)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	messy := []Code{
+		Space,
+		Code("..."),
+		None,
+		Code(" −−− "),
+		Code("·x·\u200b·"),
+		Code("  "),
+		Space,
+		Code("?!"),
+		Code("—·"),
+		Code("\t·"),
+		Space,
+	}
+
+	sanitized := Sanitize(messy)
+
+	expected := []Code{S, O, S, Space, N, E}
+	if !reflect.DeepEqual(sanitized, expected) {
+		t.Errorf("expected %v, but got %v", expected, sanitized)
+	}
+
+	if decoded, err := Decode(sanitized); err != nil {
+		t.Errorf("failed to decode sanitized codes: %s", err)
+	} else if decoded != "sos ne" {
+		t.Errorf("expected 'sos ne', but got '%s'", decoded)
+	}
+
+	if sanitized := Sanitize([]Code{None, Space, Code("x")}); len(sanitized) != 0 {
+		t.Errorf("expected no codes, but got %v", sanitized)
+	}
+}
+
+func TestFold(t *testing.T) {
+	// without folding
+	if _, err := Encode("naïve"); err == nil {
+		t.Errorf("should fail to encode 'ï' without folding")
+	}
+
+	// with folding
+	folded := Fold("naïve")
+	if folded != "naive" {
+		t.Errorf("expected 'naive', but got '%s'", folded)
+	}
+	if codes, err := Encode(folded); err != nil {
+		t.Errorf("failed to encode folded text: %s", err)
+	} else if !reflect.DeepEqual(codes, []Code{N, A, I, V, E}) {
+		t.Errorf("expected codes of 'n a i v e', but got %v", codes)
+	}
+	if codes, err := EncodeWith("naïve", EncodeOptions{FoldAccents: true}); err != nil || !reflect.DeepEqual(codes, []Code{N, A, I, V, E}) {
+		t.Errorf("expected codes of 'n a i v e', but got %v (%v)", codes, err)
+	}
+
+	for text, expected := range map[string]string{
+		"Crème Brûlée": "Creme Brulée", // 'é' has its own code
+		"Ångström":     "Angström",     // so does 'ö'
+		"señor":        "señor",
+		"øre":          "øre", // cannot be folded
+		"":             "",
+	} {
+		if folded := Fold(text); folded != expected {
+			t.Errorf("expected '%s' from '%s', but got '%s'", expected, text, folded)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package morse
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// units of on/off durations in morse timing
+const (
+	unitsDit      = 1
+	unitsDah      = 3
+	unitsIntraGap = 1 // gap between durations of a character
+	unitsCharGap  = 3 // gap between characters
+	unitsWordGap  = 7 // gap between words
+
+	unitsCallsignGap = 2 // gap between characters of a callsign, sent tighter than others
+)
+
+// converts given `codes` to an on/off timeline, one boolean per unit.
+//
+// Leading and trailing `Space`s are dropped, and consecutive ones are treated as a single word gap.
+func unitsFromCodes(codes []Code) (units []bool, err error) {
+	units = []bool{}
+
+	appendUnits := func(on bool, count int) {
+		for i := 0; i < count; i++ {
+			units = append(units, on)
+		}
+	}
+
+	wordGap := false
+	for _, code := range codes {
+		if code == Space {
+			wordGap = true
+			continue
+		}
+		if code == None {
+			return nil, fmt.Errorf("cannot convert an empty code to units")
+		}
+
+		if len(units) > 0 {
+			if wordGap {
+				appendUnits(false, unitsWordGap)
+			} else {
+				appendUnits(false, unitsCharGap)
+			}
+		}
+		wordGap = false
+
+		for i, chr := range code {
+			if i > 0 {
+				appendUnits(false, unitsIntraGap)
+			}
+
+			switch chr {
+			case ditRune:
+				appendUnits(true, unitsDit)
+			case dahRune:
+				appendUnits(true, unitsDah)
+			default:
+				return nil, fmt.Errorf("not a valid duration: '%c'", chr)
+			}
+		}
+	}
+
+	return units, nil
+}
+
+// converts given on/off timeline of units back to codes.
+//
+// Leading and trailing off units are ignored.
+func codesFromUnits(units []bool) (codes []Code, err error) {
+	codes = []Code{}
+
+	// trim off units
+	start, end := 0, len(units)
+	for start < end && !units[start] {
+		start++
+	}
+	for end > start && !units[end-1] {
+		end--
+	}
+
+	durations := []Duration{}
+	for i := start; i < end; {
+		on := units[i]
+
+		count := 0
+		for ; i < end && units[i] == on; i++ {
+			count++
+		}
+
+		if on {
+			switch count {
+			case unitsDit:
+				durations = append(durations, Dit)
+			case unitsDah:
+				durations = append(durations, Dah)
+			default:
+				return nil, fmt.Errorf("not a valid length of tone: %d units", count)
+			}
+		} else {
+			switch count {
+			case unitsIntraGap:
+				// continue the current character
+			case unitsCharGap:
+				codes = append(codes, CodeFromDurations(durations...))
+				durations = []Duration{}
+			case unitsWordGap:
+				codes = append(codes, CodeFromDurations(durations...), Space)
+				durations = []Duration{}
+			default:
+				return nil, fmt.Errorf("not a valid length of gap: %d units", count)
+			}
+		}
+	}
+	if len(durations) > 0 {
+		codes = append(codes, CodeFromDurations(durations...))
+	}
+
+	return codes, nil
+}
+
+// PackBits packs given `codes` into bytes of an on/off timeline, one bit per unit (most significant bit first).
+//
+// Leading and trailing `Space`s are dropped, and consecutive ones are packed as a single word gap.
+func PackBits(codes []Code) (packed []byte, err error) {
+	var units []bool
+	if units, err = unitsFromCodes(codes); err != nil {
+		return nil, fmt.Errorf("failed to pack '%v': %s", codes, err)
+	}
+
+	packed = make([]byte, (len(units)+7)/8)
+	for i, on := range units {
+		if on {
+			packed[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+
+	return packed, nil
+}
+
+// UnpackBits unpacks given bytes of an on/off timeline (packed with `PackBits`) to codes.
+func UnpackBits(packed []byte) (codes []Code, err error) {
+	units := make([]bool, len(packed)*8)
+	for i := range units {
+		units[i] = packed[i/8]&(0x80>>(i%8)) != 0
+	}
+
+	if codes, err = codesFromUnits(units); err != nil {
+		err = fmt.Errorf("failed to unpack bits: %s", err)
+	}
+
+	return codes, err
+}
+
+// EncodeURLSafe encodes given `text` to a compact, URL-safe string.
+//
+// The string is the unpadded base64url representation of the packed on/off timeline (see `PackBits`).
+func EncodeURLSafe(text string) (encoded string, err error) {
+	var codes []Code
+	if codes, err = Encode(text); err != nil {
+		return "", err
+	}
+
+	var packed []byte
+	if packed, err = PackBits(codes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(packed), nil
+}
+
+// DecodeURLSafe decodes given URL-safe string (encoded with `EncodeURLSafe`) to codes.
+func DecodeURLSafe(encoded string) (codes []Code, err error) {
+	var packed []byte
+	if packed, err = base64.RawURLEncoding.DecodeString(encoded); err != nil {
+		return nil, fmt.Errorf("'%s' is not a valid URL-safe string: %s", encoded, err)
+	}
+
+	return UnpackBits(packed)
+}
+
+// TryInvert converts given on/off timeline (one boolean per unit) to codes in both polarities,
+// for recovering signals captured by a detector which inverted tones and silences.
+//
+// Returns nil for a polarity which cannot be converted to valid codes.
+func TryInvert(onOff []bool) (normal, inverted []Code) {
+	normal, _ = codesFromUnits(onOff)
+
+	flipped := make([]bool, len(onOff))
+	for i, on := range onOff {
+		flipped[i] = !on
+	}
+	inverted, _ = codesFromUnits(flipped)
+
+	return normal, inverted
+}
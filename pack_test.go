@@ -0,0 +1,90 @@
+package morse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPackBits(t *testing.T) {
+	// E T: •, 7 units of word gap, −−−
+	packed, err := PackBits([]Code{E, Space, T})
+	if err != nil {
+		t.Fatalf("failed to pack bits: %s", err)
+	}
+	if len(packed) != 2 || packed[0] != 0b10000000 || packed[1] != 0b11100000 {
+		t.Errorf("unexpected packed bits: %08b", packed)
+	}
+
+	if codes, err := UnpackBits(packed); err != nil {
+		t.Errorf("failed to unpack bits: %s", err)
+	} else if decoded, _ := Decode(codes); decoded != "e t" {
+		t.Errorf("unexpected unpacked codes: %v", codes)
+	}
+
+	// invalid codes
+	if _, err := PackBits([]Code{E, None}); err == nil {
+		t.Errorf("should fail to pack an empty code")
+	}
+	if _, err := PackBits([]Code{Code("abc")}); err == nil {
+		t.Errorf("should fail to pack an invalid code")
+	}
+
+	// invalid bits
+	if _, err := UnpackBits([]byte{0b11000000}); err == nil {
+		t.Errorf("should fail to unpack a tone of 2 units")
+	}
+}
+
+func TestEncodeAndDecodeURLSafe(t *testing.T) {
+	escapedPhrase := Escape(testPhrase)
+
+	encoded, err := EncodeURLSafe(escapedPhrase)
+	if err != nil {
+		t.Fatalf("failed to encode URL-safe string: %s", err)
+	}
+	if strings.ContainsAny(encoded, "+/=") {
+		t.Errorf("encoded string is not URL-safe: %s", encoded)
+	}
+
+	if codes, err := DecodeURLSafe(encoded); err != nil {
+		t.Errorf("failed to decode URL-safe string: %s", err)
+	} else {
+		if decoded, err := Decode(codes); err != nil {
+			t.Errorf("failed to decode: %s", err)
+		} else {
+			// ignore case
+			if !strings.EqualFold(decoded, strings.TrimSpace(escapedPhrase)) {
+				t.Errorf("encoded/decoded values do not match: %s / %s", decoded, escapedPhrase)
+			}
+		}
+	}
+
+	// not a base64url string
+	if _, err := DecodeURLSafe("not/url+safe="); err == nil {
+		t.Errorf("should fail to decode an invalid string")
+	}
+}
+
+func TestTryInvert(t *testing.T) {
+	units, _ := unitsFromCodes([]Code{S, O, S})
+
+	// surrounded with silences, then inverted by the detector
+	captured := append(append(make([]bool, unitsWordGap), units...), make([]bool, unitsWordGap)...)
+	for i := range captured {
+		captured[i] = !captured[i]
+	}
+
+	normal, inverted := TryInvert(captured)
+	if normal != nil {
+		t.Errorf("normal polarity should not be decodable: %v", normal)
+	}
+	if decoded, err := Decode(inverted); err != nil || decoded != "sos" {
+		t.Errorf("inverted polarity should be decoded to 'sos': %s (%v)", decoded, err)
+	}
+
+	// not inverted
+	normal, _ = TryInvert(units)
+	if decoded, err := Decode(normal); err != nil || decoded != "sos" {
+		t.Errorf("normal polarity should be decoded to 'sos': %s (%v)", decoded, err)
+	}
+}
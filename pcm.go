@@ -0,0 +1,347 @@
+package morse
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// constants for analyzing captured audio
+const (
+	clippedFraction = 0.01 // fraction of saturated samples over which a signal is considered clipped
+
+	toneMinHz = 300  // lower bound of CW tones
+	toneMaxHz = 1200 // upper bound of CW tones
+
+	silenceLevel = 0.1 // level of silence, relative to the peak amplitude
+
+	envelopeWindow = 5 * time.Millisecond // length of the moving average for following the envelope of tones
+	envelopeLevel  = 0.5                  // level of key downs, relative to the peak of the envelope
+	envelopeMargin = 0.1                  // hysteresis around `envelopeLevel`, for not chattering at edges of noisy tones
+
+	accuracySampleRate = 8000 // sample rate of audio synthesized by `AccuracyVsSNR`
+)
+
+// IsClipped returns whether a significant fraction of given `samples` are saturated,
+// that is, their absolute values reach given `threshold`.
+func IsClipped(samples []float64, threshold float64) bool {
+	if len(samples) == 0 {
+		return false
+	}
+
+	saturated := 0
+	for _, sample := range samples {
+		if math.Abs(sample) >= threshold {
+			saturated++
+		}
+	}
+
+	return float64(saturated)/float64(len(samples)) > clippedFraction
+}
+
+// DetectToneHz estimates the frequency of the tone in given `samples`,
+// by finding the autocorrelation peak within the range of CW tones (300 ~ 1200 Hz).
+//
+// Returns 0 when no tone is found.
+func DetectToneHz(samples []float64, sampleRate int) float64 {
+	minLag := sampleRate / toneMaxHz
+	maxLag := sampleRate/toneMinHz + 1
+	if minLag < 1 || len(samples) <= maxLag+1 {
+		return 0
+	}
+
+	// autocorrelations of lags (with one more on each side for interpolation)
+	correlations := make([]float64, maxLag+2)
+	for lag := minLag - 1; lag <= maxLag+1; lag++ {
+		sum := 0.0
+		for i := 0; i+lag < len(samples); i++ {
+			sum += samples[i] * samples[i+lag]
+		}
+		correlations[lag] = sum
+	}
+
+	peak := 0.0
+	for lag := minLag; lag <= maxLag; lag++ {
+		peak = math.Max(peak, correlations[lag])
+	}
+	if peak <= 0 {
+		return 0
+	}
+
+	// the first local maximum near the peak (to avoid picking subharmonics)
+	for lag := minLag; lag <= maxLag; lag++ {
+		prev, curr, next := correlations[lag-1], correlations[lag], correlations[lag+1]
+
+		if curr >= prev && curr >= next && curr >= peak*0.9 {
+			// parabolic interpolation for a fractional lag
+			offset := 0.0
+			if denominator := prev - 2*curr + next; denominator != 0 {
+				offset = 0.5 * (prev - next) / denominator
+			}
+
+			return float64(sampleRate) / (float64(lag) + offset)
+		}
+	}
+
+	return 0
+}
+
+// SplitTransmissions splits given `samples` into segments of separate transmissions,
+// cutting at silences longer than `silence`.
+//
+// Silences around the segments are trimmed, and the segments share memory with `samples`.
+func SplitTransmissions(samples []float64, sampleRate int, silence time.Duration) (segments [][]float64) {
+	segments = [][]float64{}
+
+	peak := 0.0
+	for _, sample := range samples {
+		peak = math.Max(peak, math.Abs(sample))
+	}
+	if peak == 0 {
+		return segments
+	}
+
+	threshold := peak * silenceLevel
+	maxGap := int(float64(sampleRate) * silence.Seconds())
+
+	start, last := -1, -1
+	for i, sample := range samples {
+		if math.Abs(sample) < threshold {
+			continue
+		}
+
+		if start < 0 {
+			start = i
+		} else if i-last-1 > maxGap {
+			segments = append(segments, samples[start:last+1])
+			start = i
+		}
+		last = i
+	}
+	segments = append(segments, samples[start:last+1])
+
+	return segments
+}
+
+// DecodedSpan is a character decoded from captured audio, with its span in time
+type DecodedSpan struct {
+	DecodedChar
+
+	Start time.Duration // offset of the first tone of the character
+	End   time.Duration // offset of the start of the next character, or the end of the last tone
+}
+
+// DecodeSpans decodes given mono `samples` of captured audio to characters with their spans and confidences,
+// for highlighting them over the audio.
+//
+// Key downs are detected from the envelope of the tones, and the unit is estimated from durations of both tones and gaps,
+// so messages with only dits (eg. "hi") or only dahs (eg. "tom") are decoded too (see `ReadEvents`).
+// Each span covers a character and the gap after it, and gaps between words are spans of ' ' of their own,
+// so the spans cover the signal from the first tone to the last one contiguously.
+//
+// A confidence is how far the durations of tones and gaps in (and after) a character are
+// from the thresholds of their classifications, relative to their standard durations:
+// 1.0 for the standard ones (or longer gaps between words), and 0.0 for ones right at the thresholds.
+//
+// Will return an error when the unit cannot be estimated without ambiguity (eg. a single tone of "e" or "t"),
+// or any of the characters is unknown.
+func DecodeSpans(samples []float64, sampleRate int) (spans []DecodedSpan, err error) {
+	spans = []DecodedSpan{}
+
+	events := keyEventsFromSamples(samples, sampleRate)
+	if len(events) == 0 {
+		return spans, nil
+	}
+
+	var joined []KeyEvent
+	if joined, err = joinEvents(events); err != nil {
+		return nil, err
+	}
+	var unit time.Duration
+	if unit, err = unitOfEvents(joined); err != nil {
+		return nil, fmt.Errorf("failed to estimate the unit: %s", err)
+	}
+
+	var offset, start time.Duration
+	durations := []Duration{}
+	confidence := 1.0
+
+	complete := func(end time.Duration) error {
+		code := CodeFromDurations(durations...)
+		chr, err := codeToChar(code)
+		if err != nil {
+			return fmt.Errorf("unknown character at %s: %s", start, err)
+		}
+		spans = append(spans, DecodedSpan{DecodedChar{chr, confidence}, start, end})
+
+		durations, confidence = []Duration{}, 1.0
+		return nil
+	}
+
+	// leading and trailing key ups are not a part of any character
+	if !events[0].Down {
+		offset, events = events[0].Duration, events[1:]
+	}
+	if !events[len(events)-1].Down {
+		events = events[:len(events)-1]
+	}
+
+	for _, e := range events {
+		units := float64(e.Duration) / float64(unit)
+
+		if e.Down {
+			if len(durations) == 0 {
+				start = offset
+			}
+
+			if units < thresholdDah {
+				durations = append(durations, Dit)
+				confidence = math.Min(confidence, classificationMargin(units, unitsDit, thresholdDah))
+			} else {
+				durations = append(durations, Dah)
+				confidence = math.Min(confidence, classificationMargin(units, unitsDah, thresholdDah))
+			}
+		} else if units < thresholdCharGap {
+			confidence = math.Min(confidence, classificationMargin(units, unitsIntraGap, thresholdCharGap))
+		} else if units < thresholdWordGap {
+			threshold := float64(thresholdCharGap)
+			if units > unitsCharGap {
+				threshold = thresholdWordGap
+			}
+			confidence = math.Min(confidence, classificationMargin(units, unitsCharGap, threshold))
+
+			if err = complete(offset + e.Duration); err != nil {
+				return nil, err
+			}
+		} else {
+			if err = complete(offset); err != nil {
+				return nil, err
+			}
+			spans = append(spans, DecodedSpan{DecodedChar{' ', classificationMargin(units, unitsWordGap, thresholdWordGap)}, offset, offset + e.Duration})
+		}
+
+		offset += e.Duration
+	}
+	if err = complete(offset); err != nil {
+		return nil, err
+	}
+
+	return spans, nil
+}
+
+// returns how far given `units` are from `threshold` of their classification, relative to `standard` units,
+// clamped to 0.0 ~ 1.0 (see `DecodeSpans`)
+func classificationMargin(units, standard, threshold float64) float64 {
+	return math.Max(0, math.Min(1, math.Log(units/threshold)/math.Log(standard/threshold)))
+}
+
+// detects key downs and ups from the envelope of given mono `samples`,
+// which is the moving average of their absolute values, compared to a half of its peak (with hysteresis).
+//
+// Returns no events when `samples` are silent.
+func keyEventsFromSamples(samples []float64, sampleRate int) (events []KeyEvent) {
+	events = []KeyEvent{}
+
+	window := max(1, int(float64(sampleRate)*envelopeWindow.Seconds()))
+
+	// moving average, centered on each sample
+	sums := make([]float64, len(samples)+1)
+	for i, sample := range samples {
+		sums[i+1] = sums[i] + math.Abs(sample)
+	}
+	envelope := make([]float64, len(samples))
+	peak := 0.0
+	for i := range samples {
+		from, to := max(0, i-window/2), min(len(samples), i+window-window/2)
+		envelope[i] = (sums[to] - sums[from]) / float64(to-from)
+		peak = math.Max(peak, envelope[i])
+	}
+	if peak == 0 {
+		return events
+	}
+
+	duration := func(n int) time.Duration {
+		return time.Duration(math.Round(float64(n) * float64(time.Second) / float64(sampleRate)))
+	}
+
+	// key goes down above the upper threshold, and up below the lower one
+	upper, lower := peak*(envelopeLevel+envelopeMargin), peak*(envelopeLevel-envelopeMargin)
+
+	down, runStart := envelope[0] >= upper, 0
+	for i := 1; i <= len(envelope); i++ {
+		if i < len(envelope) && (down && envelope[i] >= lower || !down && envelope[i] < upper) {
+			continue
+		}
+
+		events = append(events, KeyEvent{Down: down, Duration: duration(i) - duration(runStart)})
+		down, runStart = !down, i
+	}
+
+	return events
+}
+
+// AccuracyVsSNR synthesizes audio of given `text` (with the default options at 8000 Hz) and adds white Gaussian noise
+// at each of the signal-to-noise ratios `snrs` (in dB, of the power of the tone to the one of the noise),
+// then decodes it with `DecodeSpans` and returns its accuracy for each of them, for documenting the decoder's performance.
+//
+// An accuracy is 1.0 minus the edit distance between the decoded characters and `text`, relative to its length (clamped to 0.0),
+// and 0.0 when the decoding fails. The noise is generated from `seed`, the same one for each of `snrs`.
+//
+// Returns nil when `text` is not encodable or has no characters.
+func AccuracyVsSNR(text string, snrs []float64, seed int64) (accuracies []float64) {
+	codes, err := Encode(text)
+	if err != nil {
+		return nil
+	}
+	decoded, err := Decode(codes)
+	if err != nil {
+		return nil
+	}
+	reference := []rune(strings.TrimSpace(decoded))
+	if len(reference) == 0 {
+		return nil
+	}
+
+	opts := DefaultBeepOptions()
+	opts.SampleRate = accuracySampleRate
+
+	clean := []float64{}
+	for _, sample := range Samples(codes, opts) {
+		clean = append(clean, sample[0])
+	}
+
+	accuracies = make([]float64, len(snrs))
+	for i, snr := range snrs {
+		random := rand.New(rand.NewSource(seed))
+
+		// power of the tone is a half of its squared amplitude
+		sigma := math.Sqrt(opts.Volume * opts.Volume / 2 / math.Pow(10, snr/10))
+
+		noisy := make([]float64, len(clean))
+		for j, sample := range clean {
+			noisy[j] = sample + random.NormFloat64()*sigma
+		}
+
+		spans, err := DecodeSpans(noisy, opts.SampleRate)
+		if err != nil {
+			continue
+		}
+
+		hypothesis := []rune{}
+		for _, span := range spans {
+			hypothesis = append(hypothesis, span.Char)
+		}
+
+		distance := 0
+		for _, e := range align(reference, hypothesis, nil) {
+			if e.op != opMatch {
+				distance++
+			}
+		}
+		accuracies[i] = math.Max(0, 1-float64(distance)/float64(len(reference)))
+	}
+
+	return accuracies
+}
@@ -0,0 +1,183 @@
+package morse
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// generates samples of a sine wave
+func sineSamples(hz float64, amplitude float64, sampleRate int, count int) []float64 {
+	samples := make([]float64, count)
+	for i := range samples {
+		samples[i] = amplitude * math.Sin(2*math.Pi*hz*float64(i)/float64(sampleRate))
+	}
+	return samples
+}
+
+func TestIsClipped(t *testing.T) {
+	clean := sineSamples(800, 0.5, 44100, 44100)
+	if IsClipped(clean, 0.99) {
+		t.Errorf("clean signal should not be clipped")
+	}
+
+	// amplify and saturate
+	clipped := sineSamples(800, 2.0, 44100, 44100)
+	for i, sample := range clipped {
+		clipped[i] = math.Max(-1, math.Min(1, sample))
+	}
+	if !IsClipped(clipped, 0.99) {
+		t.Errorf("saturated signal should be clipped")
+	}
+
+	if IsClipped([]float64{}, 0.99) {
+		t.Errorf("empty signal should not be clipped")
+	}
+}
+
+func TestDetectToneHz(t *testing.T) {
+	random := rand.New(rand.NewSource(42))
+
+	for _, hz := range []float64{400, 600, 800, 1000} {
+		samples := sineSamples(hz, 0.5, 44100, 44100/4)
+
+		// add some noise
+		for i := range samples {
+			samples[i] += (random.Float64() - 0.5) * 0.1
+		}
+
+		if detected := DetectToneHz(samples, 44100); math.Abs(detected-hz) > 5 {
+			t.Errorf("expected tone of %.0f Hz, but detected %.2f Hz", hz, detected)
+		}
+	}
+
+	// silence
+	if detected := DetectToneHz(make([]float64, 44100), 44100); detected != 0 {
+		t.Errorf("expected no tone in silence, but detected %.2f Hz", detected)
+	}
+}
+
+func TestSplitTransmissions(t *testing.T) {
+	const sampleRate = 8000
+
+	tone := sineSamples(800, 0.8, sampleRate, sampleRate/10) // 100ms tone
+	shortGap := make([]float64, sampleRate/5)                // 200ms silence
+	longGap := make([]float64, sampleRate*2)                 // 2s silence
+
+	// two transmissions of 3 and 2 tones
+	samples := []float64{}
+	samples = append(samples, longGap...)
+	for _, part := range [][]float64{
+		tone, shortGap, tone, shortGap, tone,
+		longGap,
+		tone, shortGap, tone,
+	} {
+		samples = append(samples, part...)
+	}
+	samples = append(samples, shortGap...)
+
+	segments := SplitTransmissions(samples, sampleRate, time.Second)
+	if len(segments) != 2 {
+		t.Fatalf("expected 2 segments, but got %d", len(segments))
+	}
+
+	// lengths (trimmed, so allow a few samples of difference)
+	for i, expected := range []int{
+		len(tone)*3 + len(shortGap)*2,
+		len(tone)*2 + len(shortGap),
+	} {
+		if diff := len(segments[i]) - expected; diff > 0 || diff < -4 {
+			t.Errorf("expected segment #%d of %d samples, but got %d", i, expected, len(segments[i]))
+		}
+	}
+
+	// silence only
+	if segments := SplitTransmissions(make([]float64, 100), sampleRate, time.Second); len(segments) != 0 {
+		t.Errorf("expected no segments, but got %d", len(segments))
+	}
+}
+
+func TestDecodeSpans(t *testing.T) {
+	opts := DefaultBeepOptions()
+	opts.WPM = 15
+	opts.SampleRate = 8000
+
+	// with dits and dahs, only dits, and only dahs
+	for _, text := range []string{"paris 73", "sos", "hi", "eee", "tom", "t t"} {
+		codes, _ := Encode(text)
+
+		stereo := Samples(codes, opts)
+		silence := make([]float64, opts.SampleRate/2)
+
+		// mono, with leading and trailing silences
+		samples := append([]float64{}, silence...)
+		for _, sample := range stereo {
+			samples = append(samples, sample[0])
+		}
+		samples = append(samples, silence...)
+
+		spans, err := DecodeSpans(samples, opts.SampleRate)
+		if err != nil {
+			t.Errorf("failed to decode spans of '%s': %s", text, err)
+			continue
+		}
+
+		decoded := ""
+		for _, span := range spans {
+			decoded += string(span.Char)
+
+			if span.Confidence < 0.8 {
+				t.Errorf("expected a high confidence for '%c' of a clean signal, but got %f", span.Char, span.Confidence)
+			}
+		}
+		if decoded != text {
+			t.Errorf("expected '%s', but got '%s'", text, decoded)
+		}
+
+		// contiguous from the first tone to the last one (allowing an envelope window of difference at the ends)
+		leading := time.Second / 2
+		total := time.Duration(len(stereo)) * time.Second / time.Duration(opts.SampleRate)
+		if diff := spans[0].Start - leading; diff.Abs() > envelopeWindow {
+			t.Errorf("expected spans of '%s' from %s, but got %s", text, leading, spans[0].Start)
+		}
+		if diff := spans[len(spans)-1].End - (leading + total); diff.Abs() > envelopeWindow {
+			t.Errorf("expected spans of '%s' until %s, but got %s", text, leading+total, spans[len(spans)-1].End)
+		}
+		for i := 1; i < len(spans); i++ {
+			if spans[i].Start != spans[i-1].End {
+				t.Errorf("expected '%c' to start at the end of '%c' (%s), but got %s", spans[i].Char, spans[i-1].Char, spans[i-1].End, spans[i].Start)
+			}
+		}
+	}
+
+	if spans, err := DecodeSpans(make([]float64, opts.SampleRate), opts.SampleRate); err != nil || len(spans) != 0 {
+		t.Errorf("expected no spans for silence, but got %v (%v)", spans, err)
+	}
+	if _, err := DecodeSpans(sineSamples(800, 0.8, 8000, 800), 8000); err == nil {
+		t.Errorf("should fail to estimate the unit from a single tone")
+	}
+}
+
+func TestAccuracyVsSNR(t *testing.T) {
+	snrs := []float64{-10, -5, 0, 5, 10, 20, 30}
+
+	accuracies := AccuracyVsSNR("cq de hl1abc", snrs, 42)
+	if len(accuracies) != len(snrs) {
+		t.Fatalf("expected %d accuracies, but got %d", len(snrs), len(accuracies))
+	}
+
+	// not decreasing with the SNR, and perfect for a clean signal
+	for i := 1; i < len(accuracies); i++ {
+		if accuracies[i] < accuracies[i-1] {
+			t.Errorf("expected accuracy not decreasing with SNR, but got %f at %.0f dB after %f at %.0f dB", accuracies[i], snrs[i], accuracies[i-1], snrs[i-1])
+		}
+	}
+	if accuracies[len(accuracies)-1] != 1 {
+		t.Errorf("expected a perfect accuracy at %.0f dB, but got %f", snrs[len(snrs)-1], accuracies[len(accuracies)-1])
+	}
+
+	if accuracies := AccuracyVsSNR("~", snrs, 42); accuracies != nil {
+		t.Errorf("expected nil for non-encodable text, but got %v", accuracies)
+	}
+}
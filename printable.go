@@ -0,0 +1,313 @@
+package morse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// separators of the standard printable morse string
+const (
+	standardLetterSeparator = " "
+	standardWordSeparator   = " / "
+)
+
+// rune for padding groups of letters, which looks like a space but is ignored when decoding
+const padRune = '\u00a0' // no-break space
+
+// regular expression for tokens of printable morse strings
+var regexPrintableTokens = regexp.MustCompile(`/|[^\s/\x{00a0}]+|[\s\x{00a0}]+`)
+
+// PrintableOptions for configuring printable morse strings
+type PrintableOptions struct {
+	SymbolSeparator string // between dits and dahs of a letter
+	LetterSeparator string // between letters
+	WordSeparator   string // between words
+
+	Dit, Dah string // symbols of dits and dahs (`Dit` and `Dah` when empty)
+
+	Pad bool // whether to pad the group of each letter to the same width (see `PadCode`), for aligning columns in monospaced output
+}
+
+// EncodeToString encodes given `text` to a printable morse string,
+// with `symbolSep` between dits and dahs of a letter, `letterSep` between letters, and `wordSep` between words.
+//
+// Dits and dahs are rendered as `Dit` and `Dah`, or as given `symbols` (for dit and dah, eg. "." and "-").
+// Leading, trailing, and consecutive spaces in `text` are treated as a single word separator.
+func EncodeToString(text string, symbolSep, letterSep, wordSep string, symbols ...string) (encoded string, err error) {
+	opts := PrintableOptions{
+		SymbolSeparator: symbolSep,
+		LetterSeparator: letterSep,
+		WordSeparator:   wordSep,
+	}
+	if len(symbols) > 0 {
+		if len(symbols) != 2 {
+			return "", fmt.Errorf("symbols should be given for both dit and dah: %q", symbols)
+		}
+		opts.Dit, opts.Dah = symbols[0], symbols[1]
+	}
+
+	return EncodeToStringWith(text, opts)
+}
+
+// EncodeToStringWith encodes given `text` to a printable morse string with given `opts` (see `EncodeToString`).
+//
+// When `opts.Pad` is set, groups of letters are padded with `PadCode` to the width of the longest one.
+func EncodeToStringWith(text string, opts PrintableOptions) (encoded string, err error) {
+	dit, dah := string(Dit), string(Dah)
+	if opts.Dit != "" {
+		dit = opts.Dit
+	}
+	if opts.Dah != "" {
+		dah = opts.Dah
+	}
+
+	var codes []Code
+	if codes, err = Encode(text); err != nil {
+		return "", err
+	}
+
+	// groups of letters, and whether each one follows a word gap
+	groups, wordGaps := []string{}, []bool{}
+	wordGap := false
+	for _, code := range codes {
+		if code == Space {
+			wordGap = true
+			continue
+		}
+
+		symbols := []string{}
+		for _, chr := range code {
+			switch chr {
+			case ditRune:
+				symbols = append(symbols, dit)
+			case dahRune:
+				symbols = append(symbols, dah)
+			}
+		}
+
+		groups = append(groups, strings.Join(symbols, opts.SymbolSeparator))
+		wordGaps = append(wordGaps, wordGap && len(groups) > 1)
+		wordGap = false
+	}
+
+	if opts.Pad {
+		width := 0
+		for _, group := range groups {
+			width = max(width, utf8.RuneCountInString(group))
+		}
+		for i, group := range groups {
+			groups[i] = PadCode(group, width)
+		}
+	}
+
+	var sb strings.Builder
+	for i, group := range groups {
+		if i > 0 {
+			if wordGaps[i] {
+				sb.WriteString(opts.WordSeparator)
+			} else {
+				sb.WriteString(opts.LetterSeparator)
+			}
+		}
+		sb.WriteString(group)
+	}
+
+	return sb.String(), nil
+}
+
+// PadCode pads given printable `group` of a letter with trailing no-break spaces (U+00A0) to `width` runes.
+//
+// The padding looks like spaces, but is ignored by `DecodeFromString` and `DecodeFromStringWith`,
+// so it is not taken for separators. `group` is returned as it is when it is not shorter than `width`.
+func PadCode(group string, width int) string {
+	if n := utf8.RuneCountInString(group); n < width {
+		return group + strings.Repeat(string(padRune), width-n)
+	}
+
+	return group
+}
+
+// EncodeToStandardString encodes given `text` to a printable morse string in the standard form,
+// with a space between letters and " / " between words (eg. "••• −−− ••• / •−").
+func EncodeToStandardString(text string) (encoded string, err error) {
+	return EncodeToString(text, "", standardLetterSeparator, standardWordSeparator)
+}
+
+// DecodeFromString decodes given printable morse string `s` (eg. "... --- ..." or "••• −−− •••") to a string.
+//
+// Letters are separated by spaces, and words by "/" or multiple spaces (see `DecodeFromStringWith` for other separators).
+// Dits and dahs can be written as '.' and '-', `Dit` and `Dah`, or custom `symbols` (for dit and dah) when given.
+// Leading, trailing, and repeated separators are ignored, and so is the padding of `PadCode`.
+//
+// Will return an error pointing at the first token which cannot be parsed or decoded.
+func DecodeFromString(s string, symbols ...string) (decoded string, err error) {
+	var replacer *strings.Replacer
+	if replacer, err = symbolsReplacer(symbols); err != nil {
+		return "", err
+	}
+
+	codes := []Code{}
+	wordGap := false
+	for _, loc := range regexPrintableTokens.FindAllStringIndex(s, -1) {
+		token := s[loc[0]:loc[1]]
+		unpadded := strings.ReplaceAll(token, string(padRune), "")
+
+		if token == "/" || (strings.TrimSpace(token) == "" && utf8.RuneCountInString(unpadded) >= 2) {
+			wordGap = true
+			continue
+		} else if strings.TrimSpace(token) == "" {
+			continue
+		}
+
+		var code Code
+		if code, err = parseToken(token, loc[0], replacer); err != nil {
+			return "", err
+		}
+
+		if wordGap && len(codes) > 0 {
+			codes = append(codes, Space)
+		}
+		wordGap = false
+
+		codes = append(codes, code)
+	}
+
+	return Decode(codes)
+}
+
+// DecodeFromStringWith decodes given printable morse string `s` to a string, just like `DecodeFromString`,
+// but letters are separated by any run of the characters in `separators` (eg. " \t|"),
+// and words by `wordMarker` (eg. "/"), for tolerating inconsistent separators in real-world inputs.
+// The padding of `PadCode` separates letters like `separators`.
+//
+// Will return an error when no `separators` are given, `wordMarker` includes any of them,
+// or pointing at the first token which cannot be parsed or decoded.
+func DecodeFromStringWith(s string, separators, wordMarker string, symbols ...string) (decoded string, err error) {
+	if separators == "" {
+		return "", fmt.Errorf("separators should be given")
+	}
+	if strings.ContainsAny(wordMarker, separators) {
+		return "", fmt.Errorf("word marker '%s' should not include separators: %q", wordMarker, separators)
+	}
+
+	var replacer *strings.Replacer
+	if replacer, err = symbolsReplacer(symbols); err != nil {
+		return "", err
+	}
+
+	// whether a separator or the word marker is at given position
+	isSeparator := func(pos int) bool {
+		r, _ := utf8.DecodeRuneInString(s[pos:])
+		return r == padRune || strings.ContainsRune(separators, r)
+	}
+	isWordMarker := func(pos int) bool {
+		return wordMarker != "" && strings.HasPrefix(s[pos:], wordMarker)
+	}
+
+	codes := []Code{}
+	wordGap := false
+	for pos := 0; pos < len(s); {
+		if isSeparator(pos) {
+			_, size := utf8.DecodeRuneInString(s[pos:])
+			pos += size
+			continue
+		}
+		if isWordMarker(pos) {
+			wordGap = true
+			pos += len(wordMarker)
+			continue
+		}
+
+		end := pos
+		for end < len(s) && !isSeparator(end) && !isWordMarker(end) {
+			_, size := utf8.DecodeRuneInString(s[end:])
+			end += size
+		}
+
+		var code Code
+		if code, err = parseToken(s[pos:end], pos, replacer); err != nil {
+			return "", err
+		}
+
+		if wordGap && len(codes) > 0 {
+			codes = append(codes, Space)
+		}
+		wordGap = false
+
+		codes = append(codes, code)
+		pos = end
+	}
+
+	return Decode(codes)
+}
+
+// returns a replacer of given custom `symbols` (for dit and dah) to `Dit` and `Dah`, or nil when not given
+func symbolsReplacer(symbols []string) (replacer *strings.Replacer, err error) {
+	if len(symbols) == 0 {
+		return nil, nil
+	}
+	if len(symbols) != 2 {
+		return nil, fmt.Errorf("symbols should be given for both dit and dah: %q", symbols)
+	}
+
+	return strings.NewReplacer(symbols[0], string(Dit), symbols[1], string(Dah)), nil
+}
+
+// parses given `token` of a letter at `pos` to a code, with `replacer` of custom symbols (when not nil)
+func parseToken(token string, pos int, replacer *strings.Replacer) (code Code, err error) {
+	code = Code(token)
+	if replacer != nil {
+		code = Code(replacer.Replace(string(code)))
+	}
+	code = normalizeCode(code)
+
+	if _, err = codeToChar(code); err != nil {
+		return None, fmt.Errorf("failed to parse token '%s' at %d: %s", token, pos, err)
+	}
+
+	return code, nil
+}
+
+// marks of dot arts
+const (
+	dotArtDit    = "."
+	dotArtDah    = "==="
+	dotArtBorder = "#"
+)
+
+// DotArt renders given `codes` to a decorative block of ASCII art,
+// with a row for each code (and an empty row for each word gap), framed with borders.
+//
+// Dits are rendered as ".", and dahs as "===" (3 times wider, as they last 3 times longer).
+func DotArt(codes []Code) string {
+	rows := []string{}
+	width := 0
+	for _, code := range normalizeCodes(codes) {
+		marks := []string{}
+		for _, chr := range code {
+			switch chr {
+			case ditRune:
+				marks = append(marks, dotArtDit)
+			case dahRune:
+				marks = append(marks, dotArtDah)
+			}
+		}
+
+		row := strings.Join(marks, " ")
+		rows = append(rows, row)
+		width = max(width, len(row))
+	}
+
+	var sb strings.Builder
+	border := strings.Repeat(dotArtBorder, width+4) + "\n"
+
+	sb.WriteString(border)
+	for _, row := range rows {
+		sb.WriteString(dotArtBorder + " " + row + strings.Repeat(" ", width-len(row)) + " " + dotArtBorder + "\n")
+	}
+	sb.WriteString(border)
+
+	return sb.String()
+}
@@ -0,0 +1,215 @@
+package morse
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEncodeToString(t *testing.T) {
+	if encoded, err := EncodeToStandardString("SOS  help"); err != nil {
+		t.Errorf("failed to encode: %s", err)
+	} else if expected := "••• −−− ••• / •••• • •−•• •−−•"; encoded != expected {
+		t.Errorf("expected '%s', but got '%s'", expected, encoded)
+	}
+
+	// with ASCII symbols and custom separators
+	if encoded, err := EncodeToString(" sos help ", " ", "   ", "       ", ".", "-"); err != nil {
+		t.Errorf("failed to encode: %s", err)
+	} else if expected := ". . .   - - -   . . .       . . . .   .   . - . .   . - - ."; encoded != expected {
+		t.Errorf("expected '%s', but got '%s'", expected, encoded)
+	}
+
+	// errors
+	if _, err := EncodeToStandardString("cats & dogs"); err == nil {
+		t.Errorf("should fail to encode non-encodable characters")
+	}
+	if _, err := EncodeToString("sos", "", " ", " / ", "."); err == nil {
+		t.Errorf("should fail with only one symbol")
+	}
+}
+
+func TestEncodeToStringWithPadding(t *testing.T) {
+	encoded, err := EncodeToStringWith("sos eta 0", PrintableOptions{
+		SymbolSeparator: " ",
+		LetterSeparator: "|",
+		WordSeparator:   "|/|",
+		Dit:             ".",
+		Dah:             "-",
+		Pad:             true,
+	})
+	if err != nil {
+		t.Fatalf("failed to encode: %s", err)
+	}
+
+	groups := []string{}
+	for _, word := range strings.Split(encoded, "|/|") {
+		groups = append(groups, strings.Split(word, "|")...)
+	}
+	if len(groups) != 7 {
+		t.Fatalf("expected 7 groups, but got %d: '%s'", len(groups), encoded)
+	}
+	for _, group := range groups {
+		if utf8.RuneCountInString(group) != len("- - - - -") {
+			t.Errorf("all groups should have the same width, but got '%s' in '%s'", group, encoded)
+		}
+	}
+
+	// padded groups are still decodable
+	if decoded, err := DecodeFromString(strings.NewReplacer(" ", "", "|/|", " / ", "|", " ").Replace(encoded)); err != nil || decoded != "sos eta 0" {
+		t.Errorf("expected 'sos eta 0', but got '%s' (%v)", decoded, err)
+	}
+
+	// padded strings round-trip as they are, without padding taken for word gaps
+	for _, opts := range []PrintableOptions{
+		{LetterSeparator: " ", WordSeparator: " / ", Pad: true},
+		{LetterSeparator: " ", WordSeparator: "   ", Pad: true},
+		{LetterSeparator: "", WordSeparator: " / ", Dit: ".", Dah: "-", Pad: true},
+	} {
+		padded, _ := EncodeToStringWith("sos eta 0", opts)
+		if decoded, err := DecodeFromString(padded, ".", "-"); err != nil || decoded != "sos eta 0" {
+			t.Errorf("expected 'sos eta 0' from '%s', but got '%s' (%v)", padded, decoded, err)
+		}
+	}
+	padded, _ := EncodeToStringWith("sos eta 0", PrintableOptions{LetterSeparator: "|", WordSeparator: "/", Pad: true})
+	if decoded, err := DecodeFromStringWith(padded, "|", "/"); err != nil || decoded != "sos eta 0" {
+		t.Errorf("expected 'sos eta 0' from '%s', but got '%s' (%v)", padded, decoded, err)
+	}
+
+	// without padding
+	if encoded, _ := EncodeToStringWith("et", PrintableOptions{LetterSeparator: "|"}); encoded != "•|−" {
+		t.Errorf("groups should not be padded, but got '%s'", encoded)
+	}
+}
+
+func TestPadCode(t *testing.T) {
+	for _, test := range []struct {
+		group    string
+		width    int
+		expected string
+	}{
+		{"•−", 4, "•−\u00a0\u00a0"},
+		{"•−•−", 4, "•−•−"},
+		{"•−•−•", 4, "•−•−•"},
+		{"", 2, "\u00a0\u00a0"},
+	} {
+		if padded := PadCode(test.group, test.width); padded != test.expected {
+			t.Errorf("expected '%s', but got '%s'", test.expected, padded)
+		}
+	}
+}
+
+func TestDecodeFromString(t *testing.T) {
+	for _, test := range []struct {
+		s        string
+		symbols  []string
+		expected string
+	}{
+		{"... --- ...", nil, "sos"},
+		{"••• −−− •••", nil, "sos"},
+		{"  ... --- ...  /  .... . .-.. .--.  ", nil, "sos help"},
+		{"... --- ...   .... . .-.. .--.", nil, "sos help"},
+		{"... --- .../ / /.... . .-.. .--.", nil, "sos help"},
+		{"... --- ... \t\n .... . .-.. .--.", nil, "sos help"},
+		{"oooo o oioo oiio", []string{"o", "i"}, "help"},
+		{"", nil, ""},
+	} {
+		if decoded, err := DecodeFromString(test.s, test.symbols...); err != nil {
+			t.Errorf("failed to decode '%s': %s", test.s, err)
+		} else if decoded != test.expected {
+			t.Errorf("expected '%s' from '%s', but got '%s'", test.expected, test.s, decoded)
+		}
+	}
+
+	// errors should point at the first bad token
+	if _, err := DecodeFromString("... --x ..."); err == nil || !strings.Contains(err.Error(), "'--x' at 4") {
+		t.Errorf("should fail with the position of the bad token, but got: %v", err)
+	}
+	if _, err := DecodeFromString("... ........ ..."); err == nil || !strings.Contains(err.Error(), "at 4") {
+		t.Errorf("should fail with the position of the non-decodable token, but got: %v", err)
+	}
+}
+
+func TestDecodeFromStringWith(t *testing.T) {
+	for _, test := range []struct {
+		s          string
+		separators string
+		wordMarker string
+		symbols    []string
+		expected   string
+	}{
+		{"...|---|...", "|", "/", nil, "sos"},
+		{"...  ---\t\t...", " \t|", "/", nil, "sos"},
+		{"... |\t--- \t| ...", " \t|", "/", nil, "sos"},
+		{"...|---|...|/|....\t.  .-..|.--.", " \t|", "/", nil, "sos help"},
+		{" \t...|---|.../....|.|.-..|.--.|/", " \t|", "/", nil, "sos help"},
+		{"...|---|... // ....|.|.-..|.--.", " |", "/", nil, "sos help"},
+		{"oooo\to\toioo\toiio", "\t", "", []string{"o", "i"}, "help"},
+		{"", " ", "/", nil, ""},
+	} {
+		if decoded, err := DecodeFromStringWith(test.s, test.separators, test.wordMarker, test.symbols...); err != nil {
+			t.Errorf("failed to decode '%s': %s", test.s, err)
+		} else if decoded != test.expected {
+			t.Errorf("expected '%s' from '%s', but got '%s'", test.expected, test.s, decoded)
+		}
+	}
+
+	// errors should point at the first bad token
+	if _, err := DecodeFromStringWith("...|--x|...", "|", "/"); err == nil || !strings.Contains(err.Error(), "'--x' at 4") {
+		t.Errorf("should fail with the position of the bad token, but got: %v", err)
+	}
+
+	if _, err := DecodeFromStringWith("... --- ...", "", "/"); err == nil {
+		t.Errorf("should fail without separators")
+	}
+	if _, err := DecodeFromStringWith("... --- ...", " |", "|"); err == nil {
+		t.Errorf("should fail with a word marker which includes separators")
+	}
+}
+
+func TestEncodeToStringAndDecodeFromString(t *testing.T) {
+	escapedPhrase := Escape(testPhrase)
+
+	// standard form
+	if encoded, err := EncodeToStandardString(escapedPhrase); err != nil {
+		t.Errorf("failed to encode: %s", err)
+	} else if decoded, err := DecodeFromString(encoded); err != nil {
+		t.Errorf("failed to decode: %s", err)
+	} else if !strings.EqualFold(decoded, escapedPhrase) {
+		t.Errorf("encoded/decoded values do not match: %s / %s", decoded, escapedPhrase)
+	}
+
+	// custom symbols and separators
+	if encoded, err := EncodeToString(escapedPhrase, "", " ", "   ", "o", "i"); err != nil {
+		t.Errorf("failed to encode: %s", err)
+	} else if decoded, err := DecodeFromString(encoded, "o", "i"); err != nil {
+		t.Errorf("failed to decode: %s", err)
+	} else if !strings.EqualFold(decoded, escapedPhrase) {
+		t.Errorf("encoded/decoded values do not match: %s / %s", decoded, escapedPhrase)
+	}
+}
+
+func TestDotArt(t *testing.T) {
+	codes, _ := Encode("sos hi")
+	art := DotArt(codes)
+
+	// 3 + 0 + 3, 4 + 2 dits, and 3 dahs
+	if dits := strings.Count(art, dotArtDit); dits != 12 {
+		t.Errorf("expected 12 dits, but got %d:\n%s", dits, art)
+	}
+	if dahs := strings.Count(art, dotArtDah); dahs != 3 {
+		t.Errorf("expected 3 dahs, but got %d:\n%s", dahs, art)
+	}
+
+	// a row for each code and the word gap, between borders
+	lines := strings.Split(strings.TrimSuffix(art, "\n"), "\n")
+	if len(lines) != len(codes)+2 {
+		t.Errorf("expected %d lines, but got %d:\n%s", len(codes)+2, len(lines), art)
+	}
+	for _, line := range lines {
+		if len(line) != len(lines[0]) || !strings.HasPrefix(line, dotArtBorder) || !strings.HasSuffix(line, dotArtBorder) {
+			t.Errorf("lines should be framed in the same width:\n%s", art)
+			break
+		}
+	}
+}
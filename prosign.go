@@ -0,0 +1,164 @@
+package morse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Prosign for procedural signals, which are letters run together without gaps between them
+type Prosign string
+
+// Prosigns
+const (
+	ProsignSOS Prosign = "SOS" // distress
+	ProsignAR  Prosign = "AR"  // end of message
+	ProsignSK  Prosign = "SK"  // end of contact
+	ProsignBT  Prosign = "BT"  // break, new paragraph
+	ProsignKN  Prosign = "KN"  // go ahead, named station only
+	ProsignAS  Prosign = "AS"  // wait
+	ProsignCT  Prosign = "CT"  // start of transmission
+)
+
+// regular expression for prosigns embedded in text, eg. "<SOS>"
+var regexProsign = regexp.MustCompile(`<([^<>]*)>`)
+
+// prosigns and their codes
+var prosignsMap map[Prosign]Code
+
+func init() {
+	prosignsMap = map[Prosign]Code{}
+
+	for _, prosign := range []Prosign{ProsignSOS, ProsignAR, ProsignSK, ProsignBT, ProsignKN, ProsignAS, ProsignCT} {
+		var code Code
+		for _, chr := range strings.ToLower(string(prosign)) {
+			code += codesMap[chr]
+		}
+		prosignsMap[prosign] = code
+	}
+}
+
+// Prosigns returns all supported prosigns and their codes.
+func Prosigns() map[Prosign]Code {
+	prosigns := make(map[Prosign]Code, len(prosignsMap))
+	for prosign, code := range prosignsMap {
+		prosigns[prosign] = code
+	}
+
+	return prosigns
+}
+
+// EncodeProsign encodes a prosign with given `name` (case-insensitive, eg. "SOS" or "sk") to a single morse code.
+//
+// Will return an error when no prosign has the name.
+func EncodeProsign(name string) (code Code, err error) {
+	var found bool
+	if code, found = prosignsMap[Prosign(strings.ToUpper(name))]; !found {
+		err = fmt.Errorf("no matching prosign: '%s'", name)
+	}
+
+	return code, err
+}
+
+// ContainsProsign returns the names of prosigns found in given `codes`, in order of their appearances.
+//
+// As prosigns are sent as single codes, some of them share codes with punctuations (eg. `ProsignAR` with '+'),
+// and those punctuations are also reported as prosigns.
+func ContainsProsign(codes []Code) (names []string) {
+	names = []string{}
+
+	for _, code := range codes {
+		for prosign, prosignCode := range prosignsMap {
+			if code == prosignCode {
+				names = append(names, string(prosign))
+				break
+			}
+		}
+	}
+
+	return names
+}
+
+// EncodeText encodes morse codes from given `text`, just like `Encode`,
+// but also encodes prosigns embedded in angle brackets (eg. "CQ CQ DE HL1ABC <KN>") into single codes.
+//
+// Codes of prosigns are not decoded back with `Decode` (or are decoded as punctuations sharing them), so use `DecodeText` for them.
+//
+// Will return an error when given `text` includes non-encodable characters or unknown prosigns.
+func EncodeText(text string) (codes []Code, err error) {
+	codes = []Code{}
+
+	last := 0
+	for _, loc := range regexProsign.FindAllStringSubmatchIndex(text, -1) {
+		var encoded []Code
+		if encoded, err = Encode(text[last:loc[0]]); err != nil {
+			return []Code{}, err
+		}
+		codes = append(codes, encoded...)
+
+		var code Code
+		if code, err = EncodeProsign(text[loc[2]:loc[3]]); err != nil {
+			return []Code{}, fmt.Errorf("'%s' is not encodable: %s", text, err)
+		}
+		codes = append(codes, code)
+
+		last = loc[1]
+	}
+
+	var encoded []Code
+	if encoded, err = Encode(text[last:]); err != nil {
+		return []Code{}, err
+	}
+
+	return append(codes, encoded...), nil
+}
+
+// DecodeText decodes given `codes` to a string, just like `Decode`,
+// but decodes codes of prosigns into their names in angle brackets (eg. "<SK>"), so the result of `EncodeText` is decoded back.
+//
+// As some prosigns share codes with punctuations (eg. `ProsignAR` with '+'), those punctuations are also decoded as prosigns.
+//
+// Will return an error when given `codes` include codes of neither characters nor prosigns.
+func DecodeText(codes []Code) (decoded string, err error) {
+	names := make(map[Code]Prosign, len(prosignsMap))
+	for prosign, code := range prosignsMap {
+		names[code] = prosign
+	}
+
+	var sb strings.Builder
+	for _, code := range codes {
+		if prosign, exists := names[code]; exists {
+			sb.WriteString("<" + string(prosign) + ">")
+			continue
+		}
+
+		var chr rune
+		if chr, err = codeToChar(code); err != nil {
+			return "", fmt.Errorf("'%v' are not decodable: %s", codes, err)
+		}
+		sb.WriteRune(chr)
+	}
+
+	return sb.String(), nil
+}
+
+// inserts `ProsignAR` into `codes` (encoded from `text`, one code for each character)
+// after sentence-ending punctuations which are followed by a whitespace or the end of text.
+func insertProsigns(text string, codes []Code) (inserted []Code) {
+	chars := []rune(text)
+
+	inserted = make([]Code, 0, len(codes))
+	for i, code := range codes {
+		inserted = append(inserted, code)
+
+		switch chars[i] {
+		case '.', '!', '?':
+			if i == len(chars)-1 || unicode.IsSpace(chars[i+1]) {
+				inserted = append(inserted, prosignsMap[ProsignAR])
+			}
+		}
+	}
+
+	return inserted
+}
@@ -0,0 +1,139 @@
+package morse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncodeProsign(t *testing.T) {
+	for name, expected := range map[string]Code{
+		"SOS": Code("•••−−−•••"),
+		"ar":  Code("•−•−•"),
+		"Sk":  Code("•••−•−"),
+		"BT":  Code("−•••−"),
+		"KN":  Code("−•−−•"),
+		"AS":  Code("•−•••"),
+		"CT":  Code("−•−•−"),
+	} {
+		if code, err := EncodeProsign(name); err != nil {
+			t.Errorf("failed to encode prosign '%s': %s", name, err)
+		} else if code != expected {
+			t.Errorf("expected '%s' for prosign '%s', but got '%s'", expected, name, code)
+		}
+	}
+
+	if _, err := EncodeProsign("XYZ"); err == nil {
+		t.Errorf("should fail to encode an unknown prosign")
+	}
+
+	if prosigns := Prosigns(); len(prosigns) != 7 {
+		t.Errorf("expected 7 prosigns, but got %d", len(prosigns))
+	}
+}
+
+func TestEncodeText(t *testing.T) {
+	// a prosign is a single code of nine symbols, with no gap
+	if codes, err := EncodeText("<SOS>"); err != nil {
+		t.Errorf("failed to encode: %s", err)
+	} else if len(codes) != 1 {
+		t.Errorf("expected a single code, but got %v", codes)
+	} else if symbols := []rune(codes[0]); len(symbols) != 9 {
+		t.Errorf("expected nine symbols, but got %d: '%s'", len(symbols), codes[0])
+	} else if units, _ := unitsFromCodes(codes); len(units) != 9*2-1+2*3 {
+		t.Errorf("expected only intra-character gaps, but got %d units", len(units))
+	}
+
+	// prosigns embedded in text
+	expected := []Code{Q, Space, D, E, Space, Code("•−•−•"), Space, Code("•••−•−")}
+	if codes, err := EncodeText("q de <AR> <sk>"); err != nil {
+		t.Errorf("failed to encode: %s", err)
+	} else if !reflect.DeepEqual(codes, expected) {
+		t.Errorf("expected %v, but got %v", expected, codes)
+	}
+
+	// text without prosigns
+	if codes, err := EncodeText("sos"); err != nil {
+		t.Errorf("failed to encode: %s", err)
+	} else if !reflect.DeepEqual(codes, []Code{S, O, S}) {
+		t.Errorf("expected a plain encoding, but got %v", codes)
+	}
+
+	// errors
+	for _, text := range []string{"<XYZ>", "sos <SOS", "<>"} {
+		if _, err := EncodeText(text); err == nil {
+			t.Errorf("should fail to encode '%s'", text)
+		}
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	// every prosign is decoded back
+	for prosign := range Prosigns() {
+		text := "cq de hl1abc <" + string(prosign) + ">"
+
+		codes, err := EncodeText(text)
+		if err != nil {
+			t.Fatalf("failed to encode '%s': %s", text, err)
+		}
+		if decoded, err := DecodeText(codes); err != nil {
+			t.Errorf("failed to decode '%s': %s", text, err)
+		} else if decoded != text {
+			t.Errorf("expected '%s', but got '%s'", text, decoded)
+		}
+	}
+
+	// lower-cased names are decoded in upper case
+	codes, _ := EncodeText("<sk>")
+	if decoded, _ := DecodeText(codes); decoded != "<SK>" {
+		t.Errorf("expected '<SK>', but got '%s'", decoded)
+	}
+
+	// punctuations sharing codes with prosigns
+	if decoded, _ := DecodeText([]Code{Plus}); decoded != "<AR>" {
+		t.Errorf("expected '<AR>' for '+', but got '%s'", decoded)
+	}
+
+	if _, err := DecodeText([]Code{Code(Dit + Dit + Dit + Dit + Dit + Dit + Dit + Dit)}); err == nil {
+		t.Errorf("should fail to decode an unknown code")
+	}
+}
+
+func TestAutoProsigns(t *testing.T) {
+	ar := prosignsMap[ProsignAR]
+
+	codes, err := EncodeWith("hi. 3.5 ok?", EncodeOptions{AutoProsigns: true})
+	if err != nil {
+		t.Fatalf("failed to encode with auto prosigns: %s", err)
+	}
+	if expected := []Code{H, I, Period, ar, Space, Three, Period, Five, Space, O, K, QuestionMark, ar}; !reflect.DeepEqual(codes, expected) {
+		t.Errorf("expected %v, but got %v", expected, codes)
+	}
+
+	// not inserted without the option
+	if codes, err := EncodeWith("hi.", EncodeOptions{}); err != nil {
+		t.Errorf("failed to encode: %s", err)
+	} else if expected := []Code{H, I, Period}; !reflect.DeepEqual(codes, expected) {
+		t.Errorf("expected %v, but got %v", expected, codes)
+	}
+}
+
+func TestContainsProsign(t *testing.T) {
+	codes, err := EncodeText("qsl tnx <AR> 73 <SK>")
+	if err != nil {
+		t.Fatalf("failed to encode text: %s", err)
+	}
+
+	if names := ContainsProsign(codes); !reflect.DeepEqual(names, []string{"AR", "SK"}) {
+		t.Errorf("expected prosigns AR and SK in order, but got %v", names)
+	}
+
+	codes, _ = EncodeText("<SK> <AR>")
+	if names := ContainsProsign(codes); !reflect.DeepEqual(names, []string{"SK", "AR"}) {
+		t.Errorf("expected prosigns SK and AR in order, but got %v", names)
+	}
+
+	codes, _ = Encode("cq de hl1abc")
+	if names := ContainsProsign(codes); len(names) != 0 {
+		t.Errorf("expected no prosigns, but got %v", names)
+	}
+}
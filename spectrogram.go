@@ -0,0 +1,207 @@
+package morse
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"math/bits"
+	"math/cmplx"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// constants for spectrograms
+const (
+	spectrogramWindow     = 25 * time.Millisecond // approximate length of a window
+	spectrogramMinWindow  = 64                    // minimum number of samples in a window
+	spectrogramDynamicDB  = 60.0                  // range of levels rendered from black to white
+	spectrogramHopDivisor = 4                     // hop size is a quarter of the window
+)
+
+// SpectralLine is a line of a spectrum, at `Hz` with its amplitude relative to the carrier
+type SpectralLine struct {
+	Hz        float64
+	Amplitude float64
+}
+
+// KeyingSpectrum computes the lines of the theoretical spectrum of on/off keying with `opts`,
+// for a continuous run of dits (a tone and a gap of a unit each) shaped with the ramps of tones (see `BeepOptions.Ramp`).
+//
+// Sidebands are spaced by the keying rate (a half of the units per second) around the carrier at `opts.Hz`,
+// within the range from 0 Hz to the Nyquist frequency, and harder ramps keep higher sidebands stronger (wider spectrum).
+// Returns nil when `opts` are not valid.
+func KeyingSpectrum(opts BeepOptions) (lines []SpectralLine) {
+	if err := opts.validate(); err != nil {
+		return nil
+	}
+
+	sr := opts.sampleRate()
+	unit, _, _ := opts.timings()
+	samples := sr.N(unit)
+
+	// one period of the envelope: a tone and a gap
+	period := make([]float64, 2*samples)
+	ones := beep.StreamerFunc(func(samples [][2]float64) (n int, ok bool) {
+		for i := range samples {
+			samples[i] = [2]float64{1, 1}
+		}
+		return len(samples), true
+	})
+	buf := make([][2]float64, samples)
+	n, _ := envelope(ones, samples, sr.N(opts.Ramp), opts.RampShape).Stream(buf)
+	for i := 0; i < n; i++ {
+		period[i] = buf[i][0]
+	}
+
+	// magnitude of the `harmonic`th Fourier coefficient of the envelope
+	coefficient := func(harmonic int) float64 {
+		var sum complex128
+		for i, value := range period {
+			sum += complex(value, 0) * cmplx.Exp(complex(0, -2*math.Pi*float64(harmonic*i)/float64(len(period))))
+		}
+		return cmplx.Abs(sum) / float64(len(period))
+	}
+
+	carrier := coefficient(0)
+	if carrier == 0 {
+		return []SpectralLine{}
+	}
+
+	keying := float64(sr) / float64(len(period))
+	harmonics := max(int(math.Min(float64(opts.Hz), float64(sr)/2-float64(opts.Hz))/keying), 0)
+
+	amplitudes := make([]float64, harmonics+1)
+	for harmonic := range amplitudes {
+		amplitudes[harmonic] = coefficient(harmonic) / carrier
+	}
+
+	lines = make([]SpectralLine, 0, 2*harmonics+1)
+	for harmonic := -harmonics; harmonic <= harmonics; harmonic++ {
+		lines = append(lines, SpectralLine{
+			Hz:        float64(opts.Hz) + float64(harmonic)*keying,
+			Amplitude: amplitudes[max(harmonic, -harmonic)],
+		})
+	}
+
+	return lines
+}
+
+// STFT computes the short-time Fourier transform of given `samples`,
+// with Hann windows of `windowSize` samples (a power of 2) every `hopSize` samples.
+//
+// Each frame has magnitudes of `windowSize`/2 + 1 frequency bins, from 0 Hz to the Nyquist frequency.
+// Will return an error when parameters are not valid, or `samples` are shorter than a window.
+func STFT(samples []float64, windowSize, hopSize int) (frames [][]float64, err error) {
+	if windowSize < 2 || bits.OnesCount(uint(windowSize)) != 1 {
+		return nil, fmt.Errorf("window size should be a power of 2: %d", windowSize)
+	}
+	if hopSize <= 0 {
+		return nil, fmt.Errorf("hop size should be positive: %d", hopSize)
+	}
+	if len(samples) < windowSize {
+		return nil, fmt.Errorf("too few samples for a window of %d: %d", windowSize, len(samples))
+	}
+
+	window := make([]float64, windowSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(windowSize))
+	}
+
+	frames = [][]float64{}
+	buf := make([]complex128, windowSize)
+	for start := 0; start+windowSize <= len(samples); start += hopSize {
+		for i := range buf {
+			buf[i] = complex(samples[start+i]*window[i], 0)
+		}
+		fft(buf)
+
+		magnitudes := make([]float64, windowSize/2+1)
+		for i := range magnitudes {
+			magnitudes[i] = cmplx.Abs(buf[i])
+		}
+		frames = append(frames, magnitudes)
+	}
+
+	return frames, nil
+}
+
+// WriteSpectrogramPNG renders a grayscale spectrogram of given `samples` to `w` as a PNG image.
+//
+// Time goes from left to right, and frequency from bottom (0 Hz) to top (the Nyquist frequency).
+// Levels are rendered in decibels relative to the loudest one, so quiet noise stays dark.
+func WriteSpectrogramPNG(w io.Writer, samples []float64, sampleRate int) (err error) {
+	if sampleRate <= 0 {
+		return fmt.Errorf("sample rate should be positive: %d", sampleRate)
+	}
+
+	// largest power of 2 within the window length
+	windowSize := spectrogramMinWindow
+	for windowSize*2 <= int(spectrogramWindow.Seconds()*float64(sampleRate)) {
+		windowSize *= 2
+	}
+
+	var frames [][]float64
+	if frames, err = STFT(samples, windowSize, windowSize/spectrogramHopDivisor); err != nil {
+		return fmt.Errorf("failed to render spectrogram: %s", err)
+	}
+
+	peak := 0.0
+	for _, frame := range frames {
+		for _, magnitude := range frame {
+			peak = max(peak, magnitude)
+		}
+	}
+
+	height := len(frames[0])
+	img := image.NewGray(image.Rect(0, 0, len(frames), height))
+	for x, frame := range frames {
+		for bin, magnitude := range frame {
+			level := 0.0
+			if peak > 0 && magnitude > 0 {
+				db := 20 * math.Log10(magnitude/peak)
+				level = max(0, 1+db/spectrogramDynamicDB)
+			}
+			img.SetGray(x, height-1-bin, color.Gray{Y: uint8(math.Round(level * math.MaxUint8))})
+		}
+	}
+
+	if err = png.Encode(w, img); err != nil {
+		return fmt.Errorf("failed to encode spectrogram: %s", err)
+	}
+
+	return nil
+}
+
+// transforms given `values` (of a length of a power of 2) in place with the radix-2 FFT
+func fft(values []complex128) {
+	n := len(values)
+
+	// bit-reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+
+		if i < j {
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even, odd := values[start+k], values[start+k+size/2]*w
+				values[start+k], values[start+k+size/2] = even+odd, even-odd
+				w *= step
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package morse
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"testing"
+)
+
+func TestSTFT(t *testing.T) {
+	const sampleRate, windowSize = 8000, 256
+
+	samples := sineSamples(1000, 1.0, sampleRate, sampleRate/4)
+
+	frames, err := STFT(samples, windowSize, windowSize/2)
+	if err != nil {
+		t.Fatalf("failed to compute STFT: %s", err)
+	}
+	if expected := (len(samples)-windowSize)/(windowSize/2) + 1; len(frames) != expected {
+		t.Errorf("expected %d frames, but got %d", expected, len(frames))
+	}
+
+	// peak at the bin of the tone
+	expectedBin := int(math.Round(1000 * windowSize / sampleRate))
+	for _, frame := range frames {
+		if len(frame) != windowSize/2+1 {
+			t.Fatalf("expected %d bins, but got %d", windowSize/2+1, len(frame))
+		}
+
+		peakBin := 0
+		for bin, magnitude := range frame {
+			if magnitude > frame[peakBin] {
+				peakBin = bin
+			}
+		}
+		if peakBin != expectedBin {
+			t.Errorf("expected a peak at bin %d, but got %d", expectedBin, peakBin)
+			break
+		}
+	}
+
+	// invalid parameters
+	for _, params := range [][2]int{{100, 50}, {256, 0}, {1 << 16, 128}} {
+		if _, err := STFT(samples, params[0], params[1]); err == nil {
+			t.Errorf("should fail with window size %d and hop size %d", params[0], params[1])
+		}
+	}
+}
+
+func TestWriteSpectrogramPNG(t *testing.T) {
+	const sampleRate = 8000
+
+	codes, _ := Encode("sos")
+	opts := DefaultBeepOptions()
+	opts.SampleRate = sampleRate
+
+	samples := []float64{}
+	for _, sample := range Samples(codes, opts) {
+		samples = append(samples, sample[0])
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSpectrogramPNG(&buf, samples, sampleRate); err != nil {
+		t.Fatalf("failed to write spectrogram: %s", err)
+	}
+
+	config, err := png.DecodeConfig(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to decode PNG header: %s", err)
+	}
+	if config.Width < 100 || config.Height < 64 {
+		t.Errorf("expected non-trivial dimensions, but got %dx%d", config.Width, config.Height)
+	}
+
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to decode PNG: %s", err)
+	}
+
+	// the row of the tone is brighter than the one of silent frequencies
+	bounds := img.Bounds()
+	brightness := func(hz int) (sum uint32) {
+		y := bounds.Max.Y - 1 - hz*2*(bounds.Dy()-1)/sampleRate
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, _, _, _ := img.At(x, y).RGBA()
+			sum += r >> 8
+		}
+		return sum
+	}
+	if tone, silent := brightness(opts.Hz), brightness(3000); tone <= silent {
+		t.Errorf("tone should be brighter than silent frequencies: %d / %d", tone, silent)
+	}
+
+	// invalid parameters
+	if err := WriteSpectrogramPNG(&bytes.Buffer{}, samples, 0); err == nil {
+		t.Errorf("should fail with an invalid sample rate")
+	}
+	if err := WriteSpectrogramPNG(&bytes.Buffer{}, samples[:10], sampleRate); err == nil {
+		t.Errorf("should fail with too few samples")
+	}
+}
+
+func TestKeyingSpectrum(t *testing.T) {
+	opts := DefaultBeepOptions()
+
+	// bandwidth where sidebands are stronger than -60 dB
+	width := func(lines []SpectralLine) (width float64) {
+		for _, line := range lines {
+			if line.Amplitude >= 0.001 {
+				width = math.Max(width, math.Abs(line.Hz-float64(opts.Hz)))
+			}
+		}
+		return width
+	}
+
+	smooth := KeyingSpectrum(opts)
+	if len(smooth) == 0 || len(smooth)%2 == 0 {
+		t.Fatalf("expected lines symmetric around the carrier, but got %d lines", len(smooth))
+	}
+	if carrier := smooth[len(smooth)/2]; carrier.Hz != float64(opts.Hz) || math.Abs(carrier.Amplitude-1) > 1e-9 {
+		t.Errorf("expected the carrier at %d Hz in the middle, but got %+v", opts.Hz, carrier)
+	}
+
+	// keying rate of dits
+	unit, _, _ := opts.timings()
+	if spacing := smooth[1].Hz - smooth[0].Hz; math.Abs(spacing-1/(2*unit.Seconds())) > 0.01 {
+		t.Errorf("expected sidebands spaced by the keying rate, but got %f Hz", spacing)
+	}
+
+	opts.RampShape = RampLinear
+	hard := KeyingSpectrum(opts)
+	if width(hard) <= width(smooth) {
+		t.Errorf("linear ramps should have wider sidebands than raised-cosine ones: %f / %f Hz", width(hard), width(smooth))
+	}
+
+	opts.RampShape = RampShape(-1)
+	if lines := KeyingSpectrum(opts); lines != nil {
+		t.Errorf("should return nil for invalid options, but got %d lines", len(lines))
+	}
+}
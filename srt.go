@@ -0,0 +1,51 @@
+package morse
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteSRT writes subtitles (SRT) of given `codes` to `w`, with an entry for each character timed to its playback with `opts`.
+//
+// Each entry lasts until the end of its last tone, including the ones sent repeatedly with `RepeatEach`.
+//
+// Characters are written in upper case, and codes without characters (eg. prosigns) are written as they are.
+// Will return an error when `codes` or `opts` are not valid.
+func WriteSRT(w io.Writer, codes []Code, opts BeepOptions) (err error) {
+	if err = opts.validate(); err != nil {
+		return err
+	}
+
+	starts, ends, err := opts.spans(codes)
+	if err != nil {
+		return fmt.Errorf("'%v' are not valid codes for subtitles: %s", codes, err)
+	}
+
+	index := 0
+	for i, code := range codes {
+		if code == Space {
+			continue
+		}
+		index++
+
+		text := string(code)
+		if chr, err := codeToChar(code); err == nil {
+			text = strings.ToUpper(string(chr))
+		}
+
+		if _, err = fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n", index, srtTimestamp(starts[i]), srtTimestamp(ends[i]), text); err != nil {
+			return fmt.Errorf("failed to write subtitles: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// formats given offset as a timestamp of SRT (eg. "00:01:02,345")
+func srtTimestamp(offset time.Duration) string {
+	ms := offset.Round(time.Millisecond).Milliseconds()
+
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
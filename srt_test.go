@@ -0,0 +1,120 @@
+package morse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parses given SRT timestamp (eg. "00:01:02,345")
+func parseSRTTimestamp(timestamp string) (offset time.Duration, err error) {
+	var h, m, s, ms int
+	if _, err = fmt.Sscanf(timestamp, "%02d:%02d:%02d,%03d", &h, &m, &s, &ms); err != nil {
+		return 0, err
+	}
+
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second + time.Duration(ms)*time.Millisecond, nil
+}
+
+func TestWriteSRT(t *testing.T) {
+	codes, _ := Encode(" sos  help ")
+	prosign, _ := EncodeProsign("SK")
+	codes = append(codes, prosign)
+
+	opts := DefaultBeepOptions()
+	opts.CharWPM, opts.EffectiveWPM = 18, 8
+
+	var buf bytes.Buffer
+	if err := WriteSRT(&buf, codes, opts); err != nil {
+		t.Fatalf("failed to write SRT: %s", err)
+	}
+
+	// offsets of characters
+	expected := []time.Duration{}
+	for i, offset := range StartOffsets(codes, opts) {
+		if codes[i] != Space {
+			expected = append(expected, offset)
+		}
+	}
+
+	entries := strings.Split(strings.TrimSpace(buf.String()), "\n\n")
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, but got %d:\n%s", len(expected), len(entries), buf.String())
+	}
+
+	texts := []string{}
+	for i, entry := range entries {
+		lines := strings.Split(entry, "\n")
+		if len(lines) != 3 || lines[0] != fmt.Sprint(i+1) {
+			t.Fatalf("invalid entry: %q", entry)
+		}
+
+		timestamps := strings.Split(lines[1], " --> ")
+		start, errStart := parseSRTTimestamp(timestamps[0])
+		end, errEnd := parseSRTTimestamp(timestamps[1])
+		if err := errors.Join(errStart, errEnd); err != nil {
+			t.Fatalf("invalid timestamps '%s': %s", lines[1], err)
+		}
+
+		if diff := start - expected[i]; diff < -time.Millisecond || diff > time.Millisecond {
+			t.Errorf("expected entry %d to start at %s, but got %s", i+1, expected[i], start)
+		}
+		if end <= start || (i+1 < len(expected) && end > expected[i+1]) {
+			t.Errorf("entry %d should end before the next one: %s --> %s", i+1, start, end)
+		}
+
+		texts = append(texts, lines[2])
+	}
+	if joined := strings.Join(texts, ""); joined != "SOSHELP"+string(prosign) {
+		t.Errorf("unexpected texts of entries: %s", joined)
+	}
+
+	// entries end with the last tone of repeated characters, after the preamble and with tighter gaps of the callsign
+	opts = DefaultBeepOptions()
+	opts.RepeatEach, opts.PreambleDits, opts.Callsign = 2, 3, "hl1abc"
+	codes, _ = Encode("cq de hl1abc")
+
+	buf.Reset()
+	if err := WriteSRT(&buf, codes, opts); err != nil {
+		t.Fatalf("failed to write SRT: %s", err)
+	}
+
+	// offsets where tones end
+	ends := map[time.Duration]bool{}
+	var offset time.Duration
+	for _, signal := range TimelineWith(codes, opts) {
+		offset += signal.Duration
+		if signal.On {
+			ends[offset.Round(time.Millisecond)] = true
+		}
+	}
+
+	unit, _, _ := opts.timings()
+	entries = strings.Split(strings.TrimSpace(buf.String()), "\n\n")
+	for i, entry := range entries {
+		timestamps := strings.Split(strings.Split(entry, "\n")[1], " --> ")
+		start, _ := parseSRTTimestamp(timestamps[0])
+		end, _ := parseSRTTimestamp(timestamps[1])
+
+		if !ends[end] {
+			t.Errorf("entry %d should end with a tone, but ends at %s", i+1, end)
+		}
+		if i == 0 && end-start != (2*11+3)*unit { // 'c' (-.-.) twice
+			t.Errorf("expected the first entry to last for 'c' sent twice, but got %s --> %s", start, end)
+		}
+	}
+	if last := strings.Split(entries[len(entries)-1], "\n")[1]; !strings.HasSuffix(last, srtTimestamp(offset)) {
+		t.Errorf("expected the last entry to end at %s, but got %s", srtTimestamp(offset), last)
+	}
+
+	// invalid codes and options
+	if err := WriteSRT(&bytes.Buffer{}, []Code{Code("abc")}, opts); err == nil {
+		t.Errorf("should fail with invalid codes")
+	}
+	if err := WriteSRT(&bytes.Buffer{}, codes, BeepOptions{}); err == nil {
+		t.Errorf("should fail with invalid options")
+	}
+}
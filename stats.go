@@ -0,0 +1,81 @@
+package morse
+
+import (
+	"math"
+)
+
+// symbols of the element stream for estimating information content
+const (
+	symbolDit = iota
+	symbolDah
+	symbolCharGap
+	symbolWordGap
+
+	numSymbols
+)
+
+// MorseBits estimates the information content (in bits) of given `codes`.
+//
+// Dits, dahs, and gaps between characters and words are taken as symbols,
+// and the Shannon entropy of their probabilities in the message is multiplied by the number of symbols.
+// So the result never exceeds 2 bits (for 4 kinds of symbols) per symbol,
+// and it gets smaller as the message is more repetitive.
+// Redundant (leading, trailing, and consecutive) `Space`s are collapsed as they are transmitted.
+func MorseBits(codes []Code) float64 {
+	codes = normalizeCodes(codes)
+
+	counts := [numSymbols]int{}
+
+	total := 0
+	count := func(symbol int) {
+		counts[symbol]++
+		total++
+	}
+
+	for i, code := range codes {
+		if code == Space {
+			count(symbolWordGap)
+			continue
+		}
+		if i > 0 && codes[i-1] != Space {
+			count(symbolCharGap)
+		}
+
+		for _, chr := range code {
+			switch chr {
+			case ditRune:
+				count(symbolDit)
+			case dahRune:
+				count(symbolDah)
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	entropy := 0.0
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / float64(total)
+			entropy -= p * math.Log2(p)
+		}
+	}
+
+	return entropy * float64(total)
+}
+
+// TotalElements returns the total number of elements (`Dit`s and `Dah`s) in given `codes`.
+//
+// Runes other than `Dit` and `Dah` are not counted.
+func TotalElements(codes []Code) (total int) {
+	for _, code := range codes {
+		for _, chr := range code {
+			if chr == ditRune || chr == dahRune {
+				total++
+			}
+		}
+	}
+
+	return total
+}
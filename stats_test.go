@@ -0,0 +1,51 @@
+package morse
+
+import (
+	"testing"
+)
+
+func TestMorseBits(t *testing.T) {
+	repetitive, _ := Encode("eeeeeeeeee")
+	varied, _ := Encode("morsecodes")
+
+	repetitiveBits, variedBits := MorseBits(repetitive), MorseBits(varied)
+	if repetitiveBits >= variedBits {
+		t.Errorf("repetitive message should have less information than varied one: %.2f / %.2f", repetitiveBits, variedBits)
+	}
+
+	// no more than 2 bits per symbol
+	symbols := 0
+	for _, code := range varied {
+		symbols += len([]rune(code)) + 1 // durations and a gap
+	}
+	if variedBits > float64(symbols*2) {
+		t.Errorf("information content exceeds 2 bits per symbol: %.2f bits for %d symbols", variedBits, symbols)
+	}
+
+	// a single kind of symbol has no information
+	if bits := MorseBits([]Code{O}); bits != 0 {
+		t.Errorf("expected 0 bits, but got %.2f", bits)
+	}
+	if bits := MorseBits([]Code{}); bits != 0 {
+		t.Errorf("expected 0 bits, but got %.2f", bits)
+	}
+
+	// redundant spaces are not transmitted
+	words := []Code{S, O, S, Space, E}
+	if bits, redundant := MorseBits(words), MorseBits([]Code{Space, S, O, S, Space, Space, Space, E, Space}); bits != redundant {
+		t.Errorf("redundant spaces should not change the information content: %.2f / %.2f", bits, redundant)
+	}
+}
+
+func TestTotalElements(t *testing.T) {
+	codes, _ := Encode("sos paris")
+
+	// s(3) o(3) s(3), p(4) a(2) r(3) i(2) s(3)
+	if total := TotalElements(codes); total != 23 {
+		t.Errorf("expected 23 elements, but got %d", total)
+	}
+
+	if total := TotalElements([]Code{Space, None}); total != 0 {
+		t.Errorf("expected no elements, but got %d", total)
+	}
+}
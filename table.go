@@ -0,0 +1,282 @@
+package morse
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/faiface/beep"
+)
+
+// CodeTable is a table of characters and their morse codes
+type CodeTable struct {
+	codes map[rune]Code
+	chars map[Code]rune
+}
+
+// NewCodeTable creates a new `CodeTable` with given `codes`.
+//
+// Will return an error when more than one character share the same code.
+func NewCodeTable(codes map[rune]Code) (table *CodeTable, err error) {
+	table = &CodeTable{
+		codes: make(map[rune]Code, len(codes)),
+		chars: make(map[Code]rune, len(codes)),
+	}
+
+	for chr, code := range codes {
+		if existing, exists := table.chars[code]; exists {
+			return nil, fmt.Errorf("code '%s' is shared by '%c' and '%c'", code, existing, chr)
+		}
+
+		table.codes[chr] = code
+		table.chars[code] = chr
+	}
+
+	return table, nil
+}
+
+// DefaultCodeTable returns a new `CodeTable` with the default (ITU) codes, and the registered ones (see `RegisterCode`).
+func DefaultCodeTable() *CodeTable {
+	mapsLock.RLock()
+	defer mapsLock.RUnlock()
+
+	table, _ := NewCodeTable(codesMap)
+	return table
+}
+
+// Ampersand is the code for '&', which is not in the default table.
+//
+// Operators send '&' as "es" (and), but the same pattern is also used by the AS (wait) prosign,
+// so it is only available in an optional table (see `AmpersandCodeTable`) to avoid ambiguity.
+const Ampersand Code = Code(Dit + Dah + Dit + Dit + Dit)
+
+// AmpersandCodeTable returns a new `CodeTable` with the default codes, and `Ampersand` for '&'.
+func AmpersandCodeTable() *CodeTable {
+	codes := DefaultCodeTable().Codes()
+	codes['&'] = Ampersand
+
+	table, _ := NewCodeTable(codes)
+	return table
+}
+
+// Codes returns a copy of the characters and their codes in the table.
+func (t *CodeTable) Codes() map[rune]Code {
+	codes := make(map[rune]Code, len(t.codes))
+	for chr, code := range t.codes {
+		codes[chr] = code
+	}
+	return codes
+}
+
+// Code returns the code of given character `chr`.
+func (t *CodeTable) Code(chr rune) (code Code, exists bool) {
+	code, exists = t.codes[chr]
+	return code, exists
+}
+
+// Char returns the character of given `code`.
+func (t *CodeTable) Char(code Code) (chr rune, exists bool) {
+	chr, exists = t.chars[code]
+	return chr, exists
+}
+
+// Encode encodes morse codes from given `text` with the table.
+//
+// Will return an error when given `text` includes characters not in the table.
+func (t *CodeTable) Encode(text string) (codes []Code, err error) {
+	codes = []Code{}
+
+	for _, chr := range lowerText(text) {
+		code, exists := t.codes[chr]
+		if !exists {
+			return []Code{}, fmt.Errorf("'%s' is not encodable: no matching character in the table: '%c'", text, chr)
+		}
+
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// Decode decodes given morse `codes` to a string with the table.
+//
+// Will return an error when given `codes` include codes not in the table.
+func (t *CodeTable) Decode(codes []Code) (decoded string, err error) {
+	chars := []rune{}
+
+	for _, code := range codes {
+		chr, exists := t.chars[code]
+		if !exists {
+			return "", fmt.Errorf("'%v' are not decodable: no matching code in the table: '%s'", codes, code)
+		}
+
+		chars = append(chars, chr)
+	}
+
+	return string(chars), nil
+}
+
+// RoundTrips encodes and decodes given `text` with `table` (or the default table when nil),
+// and returns whether the result matches `text` case-insensitively, along with the decoded result.
+//
+// `text` is encoded as it is with `CodeTable.Encode`, so it is useful for checking inputs with characters
+// which are folded unexpectedly (eg. "HI" is lowered to "hı" with the special case of Turkish, which is not encodable).
+// Returns false and an empty string when `text` is not encodable or decodable with `table`.
+func RoundTrips(text string, table *CodeTable) (matches bool, decoded string) {
+	if table == nil {
+		table = DefaultCodeTable()
+	}
+
+	codes, err := table.Encode(text)
+	if err != nil {
+		return false, ""
+	}
+
+	if decoded, err = table.Decode(codes); err != nil {
+		return false, ""
+	}
+
+	return strings.EqualFold(decoded, text), decoded
+}
+
+// PreviewChar returns a stream of sounds for given character `chr` in `table` (or the default table when nil),
+// with given `opts`, for auditioning the entries of a table.
+//
+// `chr` is lowered in the same way as `CodeTable.Encode`, so 'I' is lowered to the dotless 'ı' and 'İ' to 'i'.
+//
+// Will return an error when `chr` is not in the table or `opts` are not valid.
+func PreviewChar(chr rune, table *CodeTable, opts BeepOptions) (streamer beep.Streamer, err error) {
+	if table == nil {
+		table = DefaultCodeTable()
+	}
+
+	code, exists := table.Code(lowerChar(chr))
+	if !exists {
+		return nil, fmt.Errorf("no matching character in the table: '%c'", chr)
+	}
+
+	if err = opts.validate(); err != nil {
+		return nil, err
+	}
+
+	return streamCodes([]Code{code}, opts.sampleRate(), opts)
+}
+
+// CodeDifference is a character which has different codes in two tables
+type CodeDifference struct {
+	Char rune
+	A    Code
+	B    Code
+}
+
+// TableDiff is the difference between two code tables
+type TableDiff struct {
+	OnlyInA   []rune
+	OnlyInB   []rune
+	Different []CodeDifference
+}
+
+// Empty returns whether there is no difference.
+func (d TableDiff) Empty() bool {
+	return len(d.OnlyInA) == 0 && len(d.OnlyInB) == 0 && len(d.Different) == 0
+}
+
+// DiffTables compares given code tables `a` and `b`, and returns their difference.
+//
+// Characters in the difference are sorted in ascending order, and the default table is used for a nil one.
+func DiffTables(a, b *CodeTable) (diff TableDiff) {
+	if a == nil {
+		a = DefaultCodeTable()
+	}
+	if b == nil {
+		b = DefaultCodeTable()
+	}
+
+	diff = TableDiff{
+		OnlyInA:   []rune{},
+		OnlyInB:   []rune{},
+		Different: []CodeDifference{},
+	}
+
+	for chr, codeA := range a.codes {
+		if codeB, exists := b.codes[chr]; !exists {
+			diff.OnlyInA = append(diff.OnlyInA, chr)
+		} else if codeA != codeB {
+			diff.Different = append(diff.Different, CodeDifference{Char: chr, A: codeA, B: codeB})
+		}
+	}
+	for chr := range b.codes {
+		if _, exists := a.codes[chr]; !exists {
+			diff.OnlyInB = append(diff.OnlyInB, chr)
+		}
+	}
+
+	sort.Slice(diff.OnlyInA, func(i, j int) bool { return diff.OnlyInA[i] < diff.OnlyInA[j] })
+	sort.Slice(diff.OnlyInB, func(i, j int) bool { return diff.OnlyInB[i] < diff.OnlyInB[j] })
+	sort.Slice(diff.Different, func(i, j int) bool { return diff.Different[i].Char < diff.Different[j].Char })
+
+	return diff
+}
+
+// CheatSheet returns a reference of characters in given `table` and the prosigns with their ASCII codes,
+// grouped into sections of letters, digits, punctuation, and prosigns.
+//
+// Empty sections are omitted, and the default table is used when `table` is nil.
+func CheatSheet(table *CodeTable) string {
+	if table == nil {
+		table = DefaultCodeTable()
+	}
+
+	letters, digits, punctuation := []rune{}, []rune{}, []rune{}
+	for chr, code := range table.codes {
+		switch {
+		case code == Space:
+			// not printable
+		case unicode.IsLetter(chr):
+			letters = append(letters, chr)
+		case unicode.IsDigit(chr):
+			digits = append(digits, chr)
+		default:
+			punctuation = append(punctuation, chr)
+		}
+	}
+
+	var sb strings.Builder
+	writeSection := func(title string, entries [][2]string) {
+		if len(entries) == 0 {
+			return
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(title + "\n")
+		for _, entry := range entries {
+			sb.WriteString(fmt.Sprintf("%-6s %s\n", entry[0], entry[1]))
+		}
+	}
+	charEntries := func(chars []rune) (entries [][2]string) {
+		sort.Slice(chars, func(i, j int) bool { return chars[i] < chars[j] })
+		for _, chr := range chars {
+			entries = append(entries, [2]string{strings.ToUpper(string(chr)), table.codes[chr].String()})
+		}
+		return entries
+	}
+
+	prosigns := []Prosign{}
+	for prosign := range prosignsMap {
+		prosigns = append(prosigns, prosign)
+	}
+	sort.Slice(prosigns, func(i, j int) bool { return prosigns[i] < prosigns[j] })
+	prosignEntries := [][2]string{}
+	for _, prosign := range prosigns {
+		prosignEntries = append(prosignEntries, [2]string{"<" + string(prosign) + ">", prosignsMap[prosign].String()})
+	}
+
+	writeSection("LETTERS", charEntries(letters))
+	writeSection("DIGITS", charEntries(digits))
+	writeSection("PUNCTUATION", charEntries(punctuation))
+	writeSection("PROSIGNS", prosignEntries)
+
+	return sb.String()
+}
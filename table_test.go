@@ -0,0 +1,235 @@
+package morse
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewCodeTable(t *testing.T) {
+	if _, err := NewCodeTable(map[rune]Code{'a': A, 'b': A}); err == nil {
+		t.Errorf("should fail to create a table with a shared code")
+	}
+
+	table := DefaultCodeTable()
+	if code, exists := table.Code('s'); !exists || code != S {
+		t.Errorf("unexpected code for 's': %s", code)
+	}
+	if chr, exists := table.Char(O); !exists || chr != 'o' {
+		t.Errorf("unexpected character for '%s': %c", O, chr)
+	}
+
+	// modifying the copy should not affect the table
+	codes := table.Codes()
+	delete(codes, 's')
+	if _, exists := table.Code('s'); !exists {
+		t.Errorf("table should not be modified by its copy of codes")
+	}
+}
+
+func TestDiffTables(t *testing.T) {
+	table := DefaultCodeTable()
+
+	if diff := DiffTables(table, DefaultCodeTable()); !diff.Empty() {
+		t.Errorf("expected no difference, but got: %+v", diff)
+	}
+
+	// modified copy
+	codes := table.Codes()
+	delete(codes, 'q')
+	delete(codes, '0')
+	codes['0'] = T // short zero, so remove 't'
+	delete(codes, 't')
+	codes['&'] = CodeFromDurations(Dit, Dah, Dit, Dit, Dit)
+	modified, err := NewCodeTable(codes)
+	if err != nil {
+		t.Fatalf("failed to create a modified table: %s", err)
+	}
+
+	diff := DiffTables(table, modified)
+	if !reflect.DeepEqual(diff.OnlyInA, []rune{'q', 't'}) {
+		t.Errorf("unexpected characters only in a: %q", diff.OnlyInA)
+	}
+	if !reflect.DeepEqual(diff.OnlyInB, []rune{'&'}) {
+		t.Errorf("unexpected characters only in b: %q", diff.OnlyInB)
+	}
+	if !reflect.DeepEqual(diff.Different, []CodeDifference{{Char: '0', A: Zero, B: T}}) {
+		t.Errorf("unexpected different codes: %+v", diff.Different)
+	}
+
+	// nil as the default table
+	if diff := DiffTables(nil, modified); !reflect.DeepEqual(diff.OnlyInA, []rune{'q', 't'}) {
+		t.Errorf("unexpected characters only in the default table: %q", diff.OnlyInA)
+	}
+	if diff := DiffTables(table, nil); !diff.Empty() {
+		t.Errorf("expected no difference with a nil table, but got: %+v", diff)
+	}
+}
+
+func TestCodeTableEncodeAndDecode(t *testing.T) {
+	table := DefaultCodeTable()
+
+	escapedPhrase := Escape(testPhrase)
+
+	encoded, err := table.Encode(escapedPhrase)
+	if err != nil {
+		t.Fatalf("failed to encode: %s", err)
+	}
+	if expected, _ := Encode(escapedPhrase); !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("encoded values do not match: %v / %v", encoded, expected)
+	}
+
+	if decoded, err := table.Decode(encoded); err != nil {
+		t.Errorf("failed to decode: %s", err)
+	} else if expected, _ := Decode(encoded); decoded != expected {
+		t.Errorf("decoded values do not match: %s / %s", decoded, expected)
+	}
+
+	if _, err := table.Encode(testPhrase); err == nil {
+		t.Errorf("should fail to encode non-encodable characters: %s", testPhrase)
+	}
+	if _, err := table.Decode([]Code{None}); err == nil {
+		t.Errorf("should fail to decode an empty code")
+	}
+}
+
+func TestRoundTrips(t *testing.T) {
+	if matches, decoded := RoundTrips("Hello World", nil); !matches || decoded != "hello world" {
+		t.Errorf("should round-trip: %s", decoded)
+	}
+
+	// capital I is folded to the dotless 'ı' of Turkish, as `Encode` does
+	_, err := Encode("HI")
+	if matches, decoded := RoundTrips("HI", nil); matches || decoded != "" || err == nil {
+		t.Errorf("should not round-trip as `Encode` fails (%v): %s", err, decoded)
+	}
+
+	// 'İ' (dotted capital I) is folded to 'i', which does not match case-insensitively
+	if matches, decoded := RoundTrips("İstanbul", nil); matches || decoded != "istanbul" {
+		t.Errorf("should not round-trip: %s", decoded)
+	}
+
+	// not encodable at all
+	if matches, decoded := RoundTrips("Hello & bye", nil); matches || decoded != "" {
+		t.Errorf("should fail to encode: %s", decoded)
+	}
+
+	// with a custom table
+	table, _ := NewCodeTable(map[rune]Code{'s': S, 'o': O})
+	if matches, _ := RoundTrips("SOS", table); !matches {
+		t.Errorf("should round-trip with a custom table")
+	}
+	if matches, _ := RoundTrips("SOS HELP", table); matches {
+		t.Errorf("should fail to encode with a custom table")
+	}
+}
+
+func TestAmpersandCodeTable(t *testing.T) {
+	text := "cats & dogs"
+
+	if _, err := Encode(text); err == nil {
+		t.Errorf("'&' should not be encodable with the default table")
+	}
+
+	table := AmpersandCodeTable()
+
+	encoded, err := table.Encode(text)
+	if err != nil {
+		t.Fatalf("failed to encode: %s", err)
+	}
+	if encoded[5] != Ampersand {
+		t.Errorf("expected '%s' for '&', but got '%s'", Ampersand, encoded[5])
+	}
+
+	if decoded, err := table.Decode(encoded); err != nil || decoded != text {
+		t.Errorf("expected '%s', but got '%s' (%v)", text, decoded, err)
+	}
+}
+
+func TestPreviewChar(t *testing.T) {
+	opts := DefaultBeepOptions()
+
+	for _, tc := range []struct {
+		chr      rune
+		table    *CodeTable
+		expected Code
+	}{
+		{'K', nil, K},
+		{'İ', nil, I},
+		{'&', AmpersandCodeTable(), Ampersand},
+	} {
+		stream, err := PreviewChar(tc.chr, tc.table, opts)
+		if err != nil {
+			t.Fatalf("failed to preview '%c': %s", tc.chr, err)
+		}
+		if codes := codesFromStream(t, stream, opts.sampleRate(), opts); !reflect.DeepEqual(codes, []Code{tc.expected}) {
+			t.Errorf("expected %v for '%c', but streamed %v", []Code{tc.expected}, tc.chr, codes)
+		}
+	}
+
+	// capital I is lowered as `Encode` does
+	_, errEncode := Encode("I")
+	if _, err := PreviewChar('I', nil, opts); err == nil || errEncode == nil {
+		t.Errorf("expected 'I' to fail as `Encode` does (%v), but got %v", errEncode, err)
+	}
+
+	if _, err := PreviewChar('&', nil, opts); err == nil {
+		t.Errorf("should fail with a character not in the table")
+	}
+	if _, err := PreviewChar('k', nil, BeepOptions{}); err == nil {
+		t.Errorf("should fail with invalid options")
+	}
+}
+
+func TestCheatSheet(t *testing.T) {
+	sheet := CheatSheet(AmpersandCodeTable())
+
+	sections := map[string][]string{}
+	current := ""
+	for _, line := range strings.Split(sheet, "\n") {
+		if line == "" {
+			continue
+		} else if !strings.Contains(line, " ") {
+			current = line
+			continue
+		}
+		sections[current] = append(sections[current], strings.Join(strings.Fields(line), " "))
+	}
+
+	for section, expected := range map[string][]string{
+		"LETTERS":     {"A .-", "S ...", "Z --.."},
+		"DIGITS":      {"0 -----", "5 .....", "9 ----."},
+		"PUNCTUATION": {"? ..--..", "@ .--.-.", "& .-..."},
+		"PROSIGNS":    {"<SOS> ...---...", "<AR> .-.-.", "<SK> ...-.-"},
+	} {
+		entries, exists := sections[section]
+		if !exists {
+			t.Errorf("section '%s' is missing in the cheat sheet:\n%s", section, sheet)
+			continue
+		}
+		for _, entry := range expected {
+			if !slices.Contains(entries, entry) {
+				t.Errorf("entry '%s' is missing in section '%s': %v", entry, section, entries)
+			}
+		}
+	}
+
+	if n := len(sections["LETTERS"]); n != 26+7 { // with accented letters
+		t.Errorf("expected 33 letters, but got %d", n)
+	}
+	if n := len(sections["DIGITS"]); n != 10 {
+		t.Errorf("expected 10 digits, but got %d", n)
+	}
+
+	// the default table when nil
+	if sheet := CheatSheet(nil); sheet != CheatSheet(DefaultCodeTable()) {
+		t.Errorf("expected the cheat sheet of the default table, but got:\n%s", sheet)
+	}
+
+	// sections without entries are omitted
+	table, _ := NewCodeTable(map[rune]Code{'e': E, 't': T})
+	if sheet := CheatSheet(table); strings.Contains(sheet, "DIGITS") || strings.Contains(sheet, "PUNCTUATION") {
+		t.Errorf("empty sections should be omitted:\n%s", sheet)
+	}
+}
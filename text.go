@@ -0,0 +1,83 @@
+package morse
+
+import (
+	"regexp"
+	"strings"
+)
+
+// regular expression for amateur radio callsigns:
+// a prefix (1 ~ 2 letters, or a letter and a digit in either order), a digit, a suffix of 1 ~ 4 letters,
+// and an optional portable designator
+var regexCallsign = regexp.MustCompile(`(?i)\b(?:[a-z]{1,2}|[0-9][a-z]|[a-z][0-9])[0-9][a-z]{1,4}(?:/[a-z0-9]{1,3})?\b`)
+
+// DecodedChar for a character decoded with its confidence
+type DecodedChar struct {
+	Char       rune
+	Confidence float64 // 0.0 ~ 1.0
+}
+
+// SuggestBreaks returns given `decoded` text with line breaks inserted at word boundaries,
+// so that each line has no more than `maxWordsPerLine` words.
+//
+// Consecutive whitespaces are collapsed, and `decoded` is returned as it is when `maxWordsPerLine` is not positive.
+func SuggestBreaks(decoded string, maxWordsPerLine int) string {
+	if maxWordsPerLine <= 0 {
+		return decoded
+	}
+
+	words := strings.Fields(decoded)
+
+	lines := []string{}
+	for len(words) > 0 {
+		n := min(maxWordsPerLine, len(words))
+
+		lines = append(lines, strings.Join(words[:n], " "))
+		words = words[n:]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ExtractCallsigns returns likely callsigns in given `decoded` text,
+// in upper case and in the order of their first appearances.
+func ExtractCallsigns(decoded string) (callsigns []string) {
+	callsigns = []string{}
+
+	found := map[string]bool{}
+	for _, match := range regexCallsign.FindAllString(decoded, -1) {
+		callsign := strings.ToUpper(match)
+
+		if !found[callsign] {
+			found[callsign] = true
+			callsigns = append(callsigns, callsign)
+		}
+	}
+
+	return callsigns
+}
+
+// RenderWithUncertainty renders given decoded `chars` to a string,
+// wrapping characters with confidences lower than `threshold` in brackets (eg. "he[l]lo").
+//
+// Consecutive uncertain characters are wrapped together (eg. "h[el]lo").
+func RenderWithUncertainty(chars []DecodedChar, threshold float64) string {
+	var sb strings.Builder
+
+	uncertain := false
+	for _, c := range chars {
+		if low := c.Confidence < threshold; low != uncertain {
+			if low {
+				sb.WriteRune('[')
+			} else {
+				sb.WriteRune(']')
+			}
+			uncertain = low
+		}
+		sb.WriteRune(c.Char)
+	}
+	if uncertain {
+		sb.WriteRune(']')
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,68 @@
+package morse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSuggestBreaks(t *testing.T) {
+	decoded := "cq cq cq de hl1abc hl1abc k  and   some more words"
+
+	broken := SuggestBreaks(decoded, 3)
+
+	lines := strings.Split(broken, "\n")
+	if len(lines) != 4 {
+		t.Errorf("expected 4 lines, but got %d: %q", len(lines), broken)
+	}
+	for _, line := range lines {
+		if words := len(strings.Fields(line)); words > 3 || words == 0 {
+			t.Errorf("unexpected number of words in a line: %q", line)
+		}
+	}
+	if strings.Join(strings.Fields(broken), " ") != strings.Join(strings.Fields(decoded), " ") {
+		t.Errorf("words should not be changed: %q", broken)
+	}
+
+	if broken := SuggestBreaks(decoded, 0); broken != decoded {
+		t.Errorf("text should not be changed: %q", broken)
+	}
+}
+
+func TestExtractCallsigns(t *testing.T) {
+	decoded := "cq cq de hl1abc hl1abc k w1aw de hl1abc ur rst 599 5nn name is tom qth seoul tu 73 dl2xyz/p"
+
+	expected := []string{"HL1ABC", "W1AW", "DL2XYZ/P"}
+	if callsigns := ExtractCallsigns(decoded); !reflect.DeepEqual(callsigns, expected) {
+		t.Errorf("expected callsigns %v, but got %v", expected, callsigns)
+	}
+
+	if callsigns := ExtractCallsigns("the quick brown fox 73"); len(callsigns) != 0 {
+		t.Errorf("expected no callsigns, but got %v", callsigns)
+	}
+}
+
+func TestRenderWithUncertainty(t *testing.T) {
+	decoded := func(text string, confidences ...float64) (chars []DecodedChar) {
+		for i, chr := range []rune(text) {
+			chars = append(chars, DecodedChar{Char: chr, Confidence: confidences[i]})
+		}
+		return chars
+	}
+
+	for _, test := range []struct {
+		chars    []DecodedChar
+		expected string
+	}{
+		{decoded("hello", 0.9, 0.8, 0.3, 0.9, 1.0), "he[l]lo"},
+		{decoded("hello", 0.9, 0.2, 0.3, 0.9, 1.0), "h[el]lo"},
+		{decoded("hello", 0.1, 0.8, 0.9, 0.9, 0.4), "[h]ell[o]"},
+		{decoded("hi 73", 0.9, 0.5, 0.9, 0.9, 0.9), "hi 73"},
+		{decoded("sos", 0.1, 0.1, 0.1), "[sos]"},
+		{nil, ""},
+	} {
+		if rendered := RenderWithUncertainty(test.chars, 0.5); rendered != test.expected {
+			t.Errorf("expected '%s', but got '%s'", test.expected, rendered)
+		}
+	}
+}
@@ -0,0 +1,817 @@
+package morse
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TimedElement is an element (`Dit` or `Dah`) with its measured durations
+type TimedElement struct {
+	Element Duration
+	On      time.Duration // duration of the tone
+	Gap     time.Duration // duration of the silence after the tone
+}
+
+// Signal is a tone or silence of a timeline
+type Signal struct {
+	On       bool
+	Duration time.Duration
+}
+
+// the fastest speed (in WPM) searched by `WPMToFit`
+const maxWPMToFit = 10000
+
+// returns the duration of a unit (dit) at given `wpm`, with the PARIS standard (50 units per word).
+func unitDuration(wpm float64) time.Duration {
+	return time.Duration(math.Round(float64(time.Minute) / (50 * wpm)))
+}
+
+// Quantize snaps each of given on/off durations to the nearest standard multiple (1, 3, or 7)
+// of the dit unit estimated from them, for cleaning up sloppy keying.
+func Quantize(onOff []time.Duration) (quantized []time.Duration) {
+	quantized = make([]time.Duration, len(onOff))
+	if len(onOff) == 0 {
+		return quantized
+	}
+
+	// start from the shortest duration as a unit
+	unit := onOff[0]
+	for _, d := range onOff {
+		unit = min(unit, d)
+	}
+	if unit <= 0 {
+		return quantized
+	}
+
+	// refine the unit with least squares over the assigned multiples
+	multiples := make([]int64, len(onOff))
+	for iteration := 0; iteration < 3; iteration++ {
+		var sum, squares float64
+		for i, d := range onOff {
+			multiples[i] = nearestMultiple(float64(d) / float64(unit))
+
+			sum += float64(d) * float64(multiples[i])
+			squares += float64(multiples[i] * multiples[i])
+		}
+		unit = time.Duration(math.Round(sum / squares))
+	}
+
+	for i, m := range multiples {
+		quantized[i] = unit * time.Duration(m)
+	}
+
+	return quantized
+}
+
+// returns the nearest standard multiple (1, 3, or 7) of units for given number of `units`.
+func nearestMultiple(units float64) int64 {
+	switch {
+	case units < 2:
+		return unitsDit
+	case units < 5:
+		return unitsDah
+	default:
+		return unitsWordGap
+	}
+}
+
+// estimates the unit from tones of given `elements`, or from their gaps when no tone durations are given.
+func estimateUnit(elements []TimedElement) (unit time.Duration) {
+	var sum time.Duration
+	var units int64
+	for _, e := range elements {
+		if e.On <= 0 {
+			continue
+		}
+
+		sum += e.On
+		if e.Element == Dah {
+			units += unitsDah
+		} else {
+			units += unitsDit
+		}
+	}
+	if units > 0 {
+		return sum / time.Duration(units)
+	}
+
+	// the shortest gap might be the one in characters
+	for _, e := range elements {
+		if e.Gap > 0 && (unit == 0 || e.Gap < unit) {
+			unit = e.Gap
+		}
+	}
+
+	return unit
+}
+
+// codes from given timed `elements`, split at gaps between characters and words.
+func codesFromTimedElements(elements []TimedElement) (codes []Code, err error) {
+	codes = []Code{}
+	if len(elements) == 0 {
+		return codes, nil
+	}
+
+	unit := estimateUnit(elements)
+
+	durations := []Duration{}
+	for i, e := range elements {
+		if e.Element != Dit && e.Element != Dah {
+			return nil, fmt.Errorf("not a valid element at %d: '%s'", i, e.Element)
+		}
+		durations = append(durations, e.Element)
+
+		// the last gap is not needed
+		if i == len(elements)-1 || unit <= 0 {
+			continue
+		}
+
+		if units := float64(e.Gap) / float64(unit); units >= 2 {
+			codes = append(codes, CodeFromDurations(durations...))
+			durations = []Duration{}
+
+			if units >= 5 {
+				codes = append(codes, Space)
+			}
+		}
+	}
+	codes = append(codes, CodeFromDurations(durations...))
+
+	return codes, nil
+}
+
+// DecodeWithTiming decodes given timed `elements` to a string,
+// inferring boundaries of characters and words from the gaps between them.
+//
+// The unit is estimated from the tones (or the shortest gap when no tone durations are given),
+// then gaps shorter than 2 units are taken as gaps in characters, shorter than 5 units as gaps between characters,
+// and others as gaps between words.
+func DecodeWithTiming(elements []TimedElement) (decoded string, err error) {
+	var codes []Code
+	if codes, err = codesFromTimedElements(elements); err != nil {
+		return "", err
+	}
+
+	return Decode(codes)
+}
+
+// TransmissionDuration returns the duration of transmitting given `codes` at `wpm`, with the standard (PARIS) timing.
+//
+// No gap is counted before the first character, and consecutive `Space`s are counted as a single word gap,
+// including a trailing one (so "PARIS " at 1 WPM takes exactly a minute).
+// Runes other than `Dit` and `Dah` in codes are ignored. Returns 0 when `wpm` is not positive.
+func TransmissionDuration(codes []Code, wpm int) time.Duration {
+	if wpm <= 0 {
+		return 0
+	}
+
+	unit := unitDuration(float64(wpm))
+	return transmissionDuration(codes, unit, unit*unitsCharGap, unit*unitsWordGap)
+}
+
+// returns the duration of transmitting given `codes` in the same way as `TransmissionDuration`,
+// with given durations of a unit, a gap between characters, and a gap between words.
+func transmissionDuration(codes []Code, unit, charGap, wordGap time.Duration) time.Duration {
+	var units, charGaps, wordGaps int64
+	started, inWordGap := false, false
+	for _, code := range codes {
+		if code == Space {
+			inWordGap = started
+			continue
+		}
+
+		elements := 0
+		for _, chr := range code {
+			switch chr {
+			case ditRune:
+				units += unitsDit
+			case dahRune:
+				units += unitsDah
+			default:
+				continue
+			}
+
+			if elements > 0 {
+				units += unitsIntraGap
+			}
+			elements++
+		}
+		if elements == 0 {
+			continue
+		}
+
+		if started {
+			if inWordGap {
+				wordGaps++
+			} else {
+				charGaps++
+			}
+		}
+		started, inWordGap = true, false
+	}
+	if inWordGap {
+		wordGaps++
+	}
+
+	return unit*time.Duration(units) + charGap*time.Duration(charGaps) + wordGap*time.Duration(wordGaps)
+}
+
+// FarnsworthForDuration returns the effective speed (in WPM) of Farnsworth timing at which given `text` is transmitted
+// in `target` duration (in the same way as `TransmissionDuration`), with characters sent at `charWPM`.
+//
+// The speed is not rounded: for the integer `EffectiveWPM` of `BeepOptions`, rounding it up ends the transmission
+// within `target`, and rounding it down ends it no earlier than `target`.
+// Will return an error when `text` is not encodable, or `target` is not reachable by stretching the gaps
+// (eg. shorter than the duration at `charWPM`, or `text` has no gaps).
+func FarnsworthForDuration(text string, charWPM float64, target time.Duration) (effectiveWPM float64, err error) {
+	if charWPM <= 0 {
+		return 0, fmt.Errorf("character WPM should be positive: %f", charWPM)
+	}
+
+	var codes []Code
+	if codes, err = Encode(text); err != nil {
+		return 0, err
+	}
+
+	duration := func(effectiveWPM float64) time.Duration {
+		unit, charGap, wordGap := farnsworthTimings(charWPM, effectiveWPM)
+		return transmissionDuration(codes, unit, charGap, wordGap)
+	}
+
+	// slower effective speeds take longer, so search between the slowest bound and the character speed
+	slow, fast := charWPM/1000, charWPM
+	if duration(fast) > target || duration(slow) < target {
+		return 0, fmt.Errorf("'%s' cannot be transmitted in %s at %f WPM with Farnsworth timing", text, target, charWPM)
+	}
+
+	for i := 0; i < 64; i++ {
+		mid := (slow + fast) / 2
+		if duration(mid) > target {
+			slow = mid
+		} else {
+			fast = mid
+		}
+	}
+
+	return fast, nil
+}
+
+// WPMToFit returns the slowest speed (in WPM) at which given `text` is transmitted with `opts` within `budget`,
+// in the same timing as `Samples` and `BeepWith`.
+//
+// With Farnsworth timing in `opts`, the effective speed is scaled in proportion to the returned (character) speed.
+// The speed is not rounded: round it up (eg. with `math.Ceil`) for the integer speeds of `BeepOptions`,
+// as rounding down makes the transmission longer than `budget`.
+// Will return an error when `text` is not encodable or has nothing to transmit, `opts` are not valid,
+// or `budget` is too short.
+func WPMToFit(text string, budget time.Duration, opts BeepOptions) (wpm float64, err error) {
+	if err = opts.validate(); err != nil {
+		return 0, err
+	}
+
+	var codes []Code
+	if codes, err = Encode(text); err != nil {
+		return 0, err
+	}
+
+	charWPM, effectiveWPM := opts.farnsworthWPMs()
+	ratio := float64(effectiveWPM) / float64(charWPM)
+
+	duration := func(wpm float64) (duration time.Duration, err error) {
+		unit, charGap, wordGap := farnsworthTimings(wpm, wpm*ratio)
+
+		var signals []Signal
+		if signals, err = opts.scheduleAt(codes, nil, unit, charGap, wordGap); err != nil {
+			return 0, err
+		}
+		if len(signals) == 0 {
+			return 0, fmt.Errorf("'%s' has nothing to transmit", text)
+		}
+
+		for _, signal := range signals {
+			duration += signal.Duration
+		}
+		return duration, nil
+	}
+
+	// faster speeds take less time, so find a fast enough bound first
+	slow, fast := 0.0, 1.0
+	for {
+		var d time.Duration
+		if d, err = duration(fast); err != nil {
+			return 0, err
+		}
+		if d <= budget {
+			break
+		}
+
+		if slow, fast = fast, fast*2; fast > maxWPMToFit {
+			return 0, fmt.Errorf("'%s' cannot be transmitted in %s", text, budget)
+		}
+	}
+
+	for i := 0; i < 64; i++ {
+		mid := (slow + fast) / 2
+		if d, _ := duration(mid); d > budget {
+			slow = mid
+		} else {
+			fast = mid
+		}
+	}
+
+	return fast, nil
+}
+
+// StartOffsets returns the offset of each of given `codes` from the start of playback with `opts`,
+// in the same timing as `Samples` (including Farnsworth timing).
+//
+// The offset of a `Space` is where its gap starts. Leading `Space`s are at 0, and consecutive ones share the same gap.
+// Returns nil when `codes` include invalid ones.
+func StartOffsets(codes []Code, opts BeepOptions) (offsets []time.Duration) {
+	offsets = make([]time.Duration, len(codes))
+	if _, err := opts.schedule(codes, func(i int, offset time.Duration) {
+		offsets[i] = offset
+	}); err != nil {
+		return nil
+	}
+
+	return offsets
+}
+
+// TimelineWith returns the tones and silences of given `codes` with `opts` in order,
+// in the same timing as `Samples` and `BeepWith` (see `Timeline` for the standard timing).
+//
+// Returns nil when `codes` or `opts` are not valid.
+func TimelineWith(codes []Code, opts BeepOptions) (signals []Signal) {
+	if err := opts.validate(); err != nil {
+		return nil
+	}
+
+	signals, _ = opts.schedule(codes, nil)
+	return signals
+}
+
+// ElementTimings returns each element of given `codes` with the durations of its tone and the following silence,
+// in the same timing as `Samples` and `BeepWith`, for calibrating keyers.
+//
+// The gap after the last element is 0. Returns nil when `codes` or `opts` are not valid.
+func ElementTimings(codes []Code, opts BeepOptions) (elements []TimedElement) {
+	signals := TimelineWith(codes, opts)
+	if signals == nil {
+		return nil
+	}
+
+	unit, _, _ := opts.timings()
+
+	elements = []TimedElement{}
+	for _, signal := range signals {
+		if !signal.On {
+			elements[len(elements)-1].Gap = signal.Duration
+			continue
+		}
+
+		element := Dit
+		if signal.Duration > unit*unitsDit {
+			element = Dah
+		}
+		elements = append(elements, TimedElement{Element: element, On: signal.Duration})
+	}
+
+	return elements
+}
+
+// RhythmNote is a note (tone) or rest (silence) of a rhythm, with its length in beats
+type RhythmNote struct {
+	Rest  bool
+	Beats float64
+}
+
+// ToRhythm converts given `codes` to notes and rests of a rhythm with `opts`, with a beat of a unit (dit),
+// so dits are notes of 1 beat, dahs of 3 beats, and gaps are rests of 1, 3, or 7 beats (or longer with Farnsworth timing).
+//
+// Returns nil when `codes` or `opts` are not valid.
+func ToRhythm(codes []Code, opts BeepOptions) (notes []RhythmNote) {
+	signals := TimelineWith(codes, opts)
+	if signals == nil {
+		return nil
+	}
+
+	unit, _, _ := opts.timings()
+
+	notes = make([]RhythmNote, len(signals))
+	for i, signal := range signals {
+		notes[i] = RhythmNote{Rest: !signal.On, Beats: float64(signal.Duration) / float64(unit)}
+	}
+
+	return notes
+}
+
+// schedules tones and silences of given `codes` with `opts`, and calls `fn` (when not nil)
+// with the index and the start offset of each code (for a `Space`, where its gap starts).
+//
+// Leading and trailing `Space`s are dropped, and consecutive ones are treated as a single word gap.
+// Dits of the preamble are scheduled before the first character, if any, and each character is repeated as configured.
+func (o BeepOptions) schedule(codes []Code, fn func(i int, offset time.Duration)) (signals []Signal, err error) {
+	unit, charGap, wordGap := o.timings()
+
+	return o.scheduleAt(codes, fn, unit, charGap, wordGap)
+}
+
+// schedules tones and silences of given `codes` in the same way as `schedule`,
+// with given durations of a unit, a gap between characters, and a gap between words.
+func (o BeepOptions) scheduleAt(codes []Code, fn func(i int, offset time.Duration), unit, charGap, wordGap time.Duration) (signals []Signal, err error) {
+	callsign := o.callsignGaps(codes)
+
+	signals = []Signal{}
+	var offset time.Duration
+	add := func(on bool, duration time.Duration) {
+		signals = append(signals, Signal{On: on, Duration: duration})
+		offset += duration
+	}
+
+	prev, inWordGap := None, false
+	for i, code := range codes {
+		if code == Space {
+			if fn != nil {
+				fn(i, offset)
+			}
+			inWordGap = prev != None
+			continue
+		}
+		if code == None {
+			return nil, fmt.Errorf("cannot schedule an empty code")
+		}
+
+		if prev == None && o.PreambleDits > 0 {
+			for j := 0; j < o.PreambleDits; j++ {
+				if j > 0 {
+					add(false, charGap)
+				}
+				add(true, unit*unitsDit)
+			}
+			add(false, wordGap)
+		} else if prev != None {
+			if inWordGap {
+				add(false, wordGap)
+			} else if callsign[i] {
+				add(false, unit*unitsCallsignGap)
+			} else {
+				add(false, charGap+o.extraGap(prev, code, unit))
+			}
+		}
+		if fn != nil {
+			fn(i, offset)
+		}
+
+		for r := 0; r < max(o.RepeatEach, 1); r++ {
+			if r > 0 {
+				add(false, charGap)
+			}
+
+			for j, chr := range code {
+				if j > 0 {
+					add(false, unit*unitsIntraGap)
+				}
+
+				switch chr {
+				case ditRune:
+					add(true, unit*unitsDit)
+				case dahRune:
+					add(true, unit*unitsDah)
+				default:
+					return nil, fmt.Errorf("not a valid duration: '%c'", chr)
+				}
+			}
+		}
+
+		prev, inWordGap = code, false
+	}
+
+	return signals, nil
+}
+
+// returns where tones of each of given `codes` start and end in the same timing as `schedule`
+// (the end of the last one, when sent repeatedly), or where the gap starts for `Space`s
+func (o BeepOptions) spans(codes []Code) (starts, ends []time.Duration, err error) {
+	starts = make([]time.Duration, len(codes))
+	var signals []Signal
+	if signals, err = o.schedule(codes, func(i int, offset time.Duration) {
+		starts[i] = offset
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	// each tone belongs to the last character started before it (not to the preamble)
+	ends = slices.Clone(starts)
+	var offset time.Duration
+	owner, next := -1, 0
+	for _, signal := range signals {
+		if signal.On {
+			for ; next < len(codes) && starts[next] <= offset; next++ {
+				if codes[next] != Space {
+					owner = next
+				}
+			}
+			if owner >= 0 {
+				ends[owner] = offset + signal.Duration
+			}
+		}
+		offset += signal.Duration
+	}
+
+	return starts, ends, nil
+}
+
+// returns whether the gap before each of given `codes` is in the callsign of the options
+func (o BeepOptions) callsignGaps(codes []Code) (gaps []bool) {
+	gaps = make([]bool, len(codes))
+	if o.Callsign == "" {
+		return gaps
+	}
+
+	callsign, err := o.callsignCodes()
+	if err != nil || len(callsign) == 0 {
+		return gaps
+	}
+
+	for start := 0; start+len(callsign) <= len(codes); start++ {
+		if slices.Equal(codes[start:start+len(callsign)], callsign) {
+			for i := start + 1; i < start+len(callsign); i++ {
+				gaps[i] = true
+			}
+		}
+	}
+
+	return gaps
+}
+
+// returns the extra gap between given codes of adjacent characters in the same word
+func (o BeepOptions) extraGap(prev, next Code, unit time.Duration) (gap time.Duration) {
+	if o.LetterDigitGap > 0 {
+		prevChr, errPrev := codeToChar(prev)
+		nextChr, errNext := codeToChar(next)
+
+		if errPrev == nil && errNext == nil &&
+			(unicode.IsLetter(prevChr) && unicode.IsDigit(nextChr) || unicode.IsDigit(prevChr) && unicode.IsLetter(nextChr)) {
+			gap += unit * time.Duration(o.LetterDigitGap)
+		}
+	}
+
+	return gap
+}
+
+// DutyCycle returns the fraction (0.0 ~ 1.0) of time the tone is on while transmitting given `codes` with `opts`,
+// from the start of the first tone to the end of the last one (so trailing gaps are not counted).
+//
+// Returns 0 when `codes` include invalid ones or have no tones.
+func DutyCycle(codes []Code, opts BeepOptions) float64 {
+	signals, err := opts.schedule(codes, nil)
+	if err != nil {
+		return 0
+	}
+
+	var on, total time.Duration
+	for _, signal := range signals {
+		if signal.On {
+			on += signal.Duration
+		}
+		total += signal.Duration
+	}
+	if total == 0 {
+		return 0
+	}
+
+	return float64(on) / float64(total)
+}
+
+// TimelineWithDutyCycle returns the tones and silences of given `codes` with `opts` (as `TimelineWith` does) with cooling pauses,
+// so the tone is on for no longer than `maxDuty` (0.0 ~ 1.0) of any sliding `window`, for protecting transmitters.
+//
+// Pauses are made only by stretching gaps between words, from the latest ones in the exceeded window,
+// so the tones and what they mean are not changed. They are returned as a timeline, as codes cannot express them:
+// consecutive `Space`s are sent as a single word gap, and other codes would change the message.
+//
+// Will return an error when `codes` or the arguments are not valid,
+// or when the limit cannot be kept with pauses (eg. a word itself is too long for the window).
+func TimelineWithDutyCycle(codes []Code, maxDuty float64, window time.Duration, opts BeepOptions) (signals []Signal, err error) {
+	if err = opts.validate(); err != nil {
+		return nil, err
+	}
+	if maxDuty <= 0 || maxDuty > 1 {
+		return nil, fmt.Errorf("max duty cycle should be in (0.0, 1.0]: %f", maxDuty)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("window should be positive: %s", window)
+	}
+
+	limit := time.Duration(maxDuty * float64(window))
+
+	// gaps between words start where `Space`s are scheduled
+	spaces := map[time.Duration]bool{}
+	if signals, err = opts.schedule(codes, func(i int, offset time.Duration) {
+		if codes[i] == Space {
+			spaces[offset] = true
+		}
+	}); err != nil {
+		return nil, err
+	}
+	wordGaps := make([]bool, len(signals))
+	var offset time.Duration
+	for i, signal := range signals {
+		wordGaps[i] = !signal.On && spaces[offset]
+		offset += signal.Duration
+	}
+
+	// windows starting at tones have the most of them
+	for i, signal := range signals {
+		if !signal.On {
+			continue
+		}
+
+		var elapsed, on, gapStart time.Duration
+		gap := -1
+		for k := i; k < len(signals) && elapsed < window; k++ {
+			if signals[k].On {
+				on += min(signals[k].Duration, window-elapsed)
+			} else if wordGaps[k] && on <= limit {
+				gap, gapStart = k, elapsed
+			}
+			elapsed += signals[k].Duration
+		}
+		if on <= limit {
+			continue
+		}
+		if gap < 0 {
+			return nil, fmt.Errorf("cannot keep the duty cycle under %f in windows of %s: '%v'", maxDuty, window, codes)
+		}
+
+		// push the tones after the gap out of the window, leaving ones within the limit before it
+		signals[gap].Duration = window - gapStart
+	}
+
+	return signals, nil
+}
+
+// MergeOverSplit returns a copy of given timed `elements`, with characters split by false gaps merged back.
+//
+// Characters are split at gaps in the same way as `DecodeWithTiming`, and when a character is not in `table`,
+// it is merged with its next (or previous) neighbor across a gap between characters if the merged one is in `table`.
+// Merged gaps are shortened to a unit. The default table is used when `table` is nil.
+func MergeOverSplit(elements []TimedElement, table *CodeTable) (merged []TimedElement) {
+	if table == nil {
+		table = DefaultCodeTable()
+	}
+
+	merged = append([]TimedElement{}, elements...)
+
+	unit := estimateUnit(merged)
+	if unit <= 0 {
+		return merged
+	}
+
+	// characters, as ranges of elements
+	type segment struct {
+		start, end int
+	}
+	segments := []segment{}
+	for i, start := 0, 0; i < len(merged); i++ {
+		if i == len(merged)-1 || float64(merged[i].Gap)/float64(unit) >= 2 {
+			segments = append(segments, segment{start: start, end: i + 1})
+			start = i + 1
+		}
+	}
+
+	valid := func(s segment) bool {
+		durations := []Duration{}
+		for _, e := range merged[s.start:s.end] {
+			durations = append(durations, e.Element)
+		}
+
+		_, exists := table.Char(CodeFromDurations(durations...))
+		return exists
+	}
+	// not across a gap between words
+	mergeable := func(s segment) bool {
+		return float64(merged[s.end-1].Gap)/float64(unit) < 5
+	}
+
+	for i := 0; i < len(segments); i++ {
+		if valid(segments[i]) {
+			continue
+		}
+
+		// with the next one
+		if i+1 < len(segments) && mergeable(segments[i]) {
+			if joined := (segment{start: segments[i].start, end: segments[i+1].end}); valid(joined) {
+				merged[segments[i].end-1].Gap = unit
+				segments[i] = joined
+				segments = append(segments[:i+1], segments[i+2:]...)
+				continue
+			}
+		}
+
+		// with the previous one
+		if i > 0 && mergeable(segments[i-1]) {
+			if joined := (segment{start: segments[i-1].start, end: segments[i].end}); valid(joined) {
+				merged[segments[i-1].end-1].Gap = unit
+				segments[i-1] = joined
+				segments = append(segments[:i], segments[i+1:]...)
+				i--
+			}
+		}
+	}
+
+	return merged
+}
+
+// tags of options hashed in `TimingFingerprint`, for keeping values of different fields apart
+const (
+	fingerprintHz byte = iota + 1
+	fingerprintCharWPM
+	fingerprintEffectiveWPM
+	fingerprintVolume
+	fingerprintSampleRate
+	fingerprintRamp
+	fingerprintRampShape
+	fingerprintMessageFadeIn
+	fingerprintMessageFadeOut
+	fingerprintLetterDigitGap
+	fingerprintCallsign
+	fingerprintPreambleDits
+	fingerprintRepeatEach
+	fingerprintRounding
+	fingerprintBitDepth
+)
+
+// TimingFingerprint returns a stable hash of the on/off timeline of given `codes` and `opts`,
+// which can be used as a key for caching rendered audio.
+//
+// Every option which affects the audio is hashed with a tag of its own, in a fixed order,
+// and options which render the same audio (eg. zero and 44100 for `SampleRate`) are hashed the same.
+// Codes which cannot be converted to a timeline are hashed as they are.
+func TimingFingerprint(codes []Code, opts BeepOptions) uint64 {
+	h := fnv.New64a()
+
+	// options
+	buf := []byte{}
+	field := func(tag byte, value uint64) {
+		buf = append(buf, tag)
+		buf = binary.LittleEndian.AppendUint64(buf, value)
+	}
+	charWPM, effectiveWPM := opts.farnsworthWPMs()
+	field(fingerprintHz, uint64(opts.Hz))
+	field(fingerprintCharWPM, uint64(charWPM))
+	field(fingerprintEffectiveWPM, uint64(effectiveWPM))
+	field(fingerprintVolume, math.Float64bits(opts.Volume))
+	field(fingerprintSampleRate, uint64(opts.sampleRate()))
+	field(fingerprintRamp, uint64(opts.Ramp))
+	field(fingerprintRampShape, uint64(opts.RampShape))
+	field(fingerprintMessageFadeIn, uint64(opts.MessageFadeIn))
+	field(fingerprintMessageFadeOut, uint64(opts.MessageFadeOut))
+	field(fingerprintLetterDigitGap, uint64(opts.LetterDigitGap))
+	callsign := strings.ToLower(opts.Callsign) // lowered as in `callsignCodes`
+	field(fingerprintCallsign, uint64(len(callsign)))
+	buf = append(buf, callsign...)
+	field(fingerprintPreambleDits, uint64(opts.PreambleDits))
+	field(fingerprintRepeatEach, uint64(max(opts.RepeatEach, 1)))
+	field(fingerprintRounding, uint64(opts.Rounding))
+	field(fingerprintBitDepth, uint64(opts.bitDepth()))
+	h.Write(buf)
+
+	// timeline
+	if units, err := unitsFromCodes(codes); err == nil {
+		for _, on := range units {
+			if on {
+				h.Write([]byte{1})
+			} else {
+				h.Write([]byte{0})
+			}
+		}
+	} else {
+		h.Write([]byte{0xff}) // separate from valid timelines
+		for _, code := range codes {
+			h.Write([]byte(code))
+			h.Write([]byte{0})
+		}
+	}
+
+	return h.Sum64()
+}
+
+// Timeline returns the tones and silences of given `codes` at `wpm` in order, with the standard morse timing:
+// gaps of 1 unit between durations of a character, 3 units between characters, and 7 units between words.
+//
+// Leading and trailing `Space`s are dropped. Returns nil when `codes` include invalid ones or `wpm` is not positive.
+func Timeline(codes []Code, wpm int) (signals []Signal) {
+	if wpm <= 0 {
+		return nil
+	}
+
+	return (&Encoder{opts: BeepOptions{WPM: wpm}}).Timeline(codes)
+}
@@ -0,0 +1,783 @@
+package morse
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+func TestUnitDuration(t *testing.T) {
+	if unit := unitDuration(wpm); unit != 120*time.Millisecond {
+		t.Errorf("unit duration at %d WPM does not match: %s", wpm, unit)
+	}
+
+	// awkward speeds, where integer milliseconds are truncated
+	for _, wpm := range []float64{7, 13, 17, 23, 7.5} {
+		exact := float64(time.Minute) / (50 * wpm) // PARIS: 50 units per word
+
+		unit := unitDuration(wpm)
+		if diff := math.Abs(float64(unit) - exact); diff > 0.5 {
+			t.Errorf("unit at %.1f WPM differs from the exact value by %.2f ns: %s", wpm, diff, unit)
+		}
+
+		// a dah (3 units) and a word gap (7 units)
+		for _, units := range []float64{unitsDah, unitsWordGap} {
+			if diff := math.Abs(float64(unit)*units - exact*units); diff > units {
+				t.Errorf("%.0f units at %.1f WPM differ from the exact value by %.2f ns", units, wpm, diff)
+			}
+		}
+	}
+}
+
+func TestSamplesDoNotDrift(t *testing.T) {
+	// "paris paris paris" is 3 words of 50 units, without the last word gap of 7 units
+	codes, _ := Encode("paris paris paris")
+	const units = 3*50 - unitsWordGap
+
+	for _, wpm := range []int{7, 13, 17, 23} {
+		opts := DefaultBeepOptions()
+		opts.WPM = wpm
+
+		exact := float64(units) * float64(time.Minute) / (50 * float64(wpm)) * float64(opts.SampleRate) / float64(time.Second)
+		for _, mode := range []RoundingMode{RoundTruncate, RoundNearest, RoundNearestEven} {
+			opts.Rounding = mode
+			if samples := len(Samples(codes, opts)); math.Abs(float64(samples)-exact) > 1 {
+				t.Errorf("expected %.2f samples at %d WPM (rounding: %d), but got %d", exact, wpm, mode, samples)
+			}
+		}
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	ms := time.Millisecond
+
+	// sloppy "a e": •, gap, −, word gap, •
+	jittery := []time.Duration{95 * ms, 110 * ms, 320 * ms, 680 * ms, 104 * ms}
+	multiples := []time.Duration{1, 1, 3, 7, 1}
+
+	quantized := Quantize(jittery)
+	if len(quantized) != len(jittery) {
+		t.Fatalf("expected %d durations, but got %d", len(jittery), len(quantized))
+	}
+
+	unit := quantized[0]
+	if unit < 95*ms || unit > 105*ms {
+		t.Errorf("unexpected unit: %s", unit)
+	}
+	for i, d := range quantized {
+		if d != unit*multiples[i] {
+			t.Errorf("expected %s (%d units), but got %s", unit*multiples[i], multiples[i], d)
+		}
+	}
+
+	if quantized := Quantize([]time.Duration{}); len(quantized) != 0 {
+		t.Errorf("expected no durations, but got %v", quantized)
+	}
+}
+
+func TestDecodeWithTiming(t *testing.T) {
+	ms := time.Millisecond
+
+	// "hi there" at about 100ms per unit, with some jitter
+	timed := func(code Code, last time.Duration) []TimedElement {
+		elements := []TimedElement{}
+		for i, chr := range []rune(code) {
+			e := TimedElement{Element: Duration(chr), On: 98 * ms, Gap: 103 * ms}
+			if e.Element == Dah {
+				e.On = 305 * ms
+			}
+			if i == len([]rune(code))-1 {
+				e.Gap = last
+			}
+			elements = append(elements, e)
+		}
+		return elements
+	}
+
+	elements := []TimedElement{}
+	for _, part := range [][]TimedElement{
+		timed(H, 290*ms), timed(I, 710*ms),
+		timed(T, 310*ms), timed(H, 295*ms), timed(E, 302*ms), timed(R, 288*ms), timed(E, 0),
+	} {
+		elements = append(elements, part...)
+	}
+
+	if decoded, err := DecodeWithTiming(elements); err != nil || decoded != "hi there" {
+		t.Errorf("expected 'hi there', but got '%s' (%v)", decoded, err)
+	}
+
+	// without tone durations, the shortest gap is taken as the unit
+	for i := range elements {
+		elements[i].On = 0
+	}
+	if decoded, err := DecodeWithTiming(elements); err != nil || decoded != "hi there" {
+		t.Errorf("expected 'hi there', but got '%s' (%v)", decoded, err)
+	}
+
+	// invalid element
+	if _, err := DecodeWithTiming([]TimedElement{{Element: Duration("x")}}); err == nil {
+		t.Errorf("should fail to decode an invalid element")
+	}
+}
+
+func TestTransmissionDuration(t *testing.T) {
+	paris, _ := Encode("paris ")
+	if duration := TransmissionDuration(paris, 1); duration != time.Minute {
+		t.Errorf("expected 'PARIS ' at 1 WPM to take a minute, but took %s", duration)
+	}
+	if duration := TransmissionDuration(paris, 20); duration != 3*time.Second {
+		t.Errorf("expected 'PARIS ' at 20 WPM to take 3 seconds, but took %s", duration)
+	}
+
+	unit := unitDuration(10)
+	for text, units := range map[string]int64{
+		"e":      1,
+		"  e":    1, // no leading gap
+		"ee":     1 + 3 + 1,
+		"e e":    1 + 7 + 1,
+		"e   e ": 1 + 7 + 1 + 7, // consecutive spaces as a single word gap
+		"a":      1 + 1 + 3,
+		"":       0,
+	} {
+		codes, _ := Encode(text)
+		if duration := TransmissionDuration(codes, 10); duration != unit*time.Duration(units) {
+			t.Errorf("expected '%s' to take %d units, but took %s", text, units, duration)
+		}
+	}
+
+	// matches the timeline, without trailing spaces
+	codes, _ := Encode("hello world")
+	var total time.Duration
+	for _, signal := range Timeline(codes, 13) {
+		total += signal.Duration
+	}
+	if duration := TransmissionDuration(codes, 13); duration != total {
+		t.Errorf("expected %s, but got %s", total, duration)
+	}
+
+	if duration := TransmissionDuration(paris, 0); duration != 0 {
+		t.Errorf("expected 0 for an invalid speed, but got %s", duration)
+	}
+}
+
+func TestFarnsworthForDuration(t *testing.T) {
+	text := "paris paris "
+	codes, _ := Encode(text)
+
+	// duration of known Farnsworth timing
+	unit, charGap, wordGap := BeepOptions{WPM: 18, EffectiveWPM: 10}.timings()
+	target := transmissionDuration(codes, unit, charGap, wordGap)
+
+	effectiveWPM, err := FarnsworthForDuration(text, 18, target)
+	if err != nil {
+		t.Fatalf("failed to solve the effective WPM: %s", err)
+	}
+	if math.Abs(effectiveWPM-10) > 0.01 {
+		t.Errorf("expected an effective speed of 10 WPM, but got %f", effectiveWPM)
+	}
+
+	// close to the target
+	target = 20 * time.Second
+	if effectiveWPM, err = FarnsworthForDuration(text, 18, target); err != nil {
+		t.Fatalf("failed to solve the effective WPM: %s", err)
+	}
+	unit, charGap, wordGap = farnsworthTimings(18, effectiveWPM)
+	if duration := transmissionDuration(codes, unit, charGap, wordGap); (duration - target).Abs() > time.Millisecond {
+		t.Errorf("expected a duration close to %s, but got %s at %f WPM", target, duration, effectiveWPM)
+	}
+
+	// rounded for the integer speeds of options
+	unit, charGap, wordGap = BeepOptions{WPM: 18, EffectiveWPM: int(math.Ceil(effectiveWPM))}.timings()
+	if duration := transmissionDuration(codes, unit, charGap, wordGap); duration > target {
+		t.Errorf("expected a duration within %s when rounded up, but got %s", target, duration)
+	}
+	unit, charGap, wordGap = BeepOptions{WPM: 18, EffectiveWPM: int(math.Floor(effectiveWPM))}.timings()
+	if duration := transmissionDuration(codes, unit, charGap, wordGap); duration < target {
+		t.Errorf("expected a duration no shorter than %s when rounded down, but got %s", target, duration)
+	}
+
+	// shorter than the duration at the character speed
+	if _, err := FarnsworthForDuration(text, 18, TransmissionDuration(codes, 18)-time.Second); err == nil {
+		t.Errorf("should fail with a target which is too short")
+	}
+
+	// no gaps to stretch
+	if _, err := FarnsworthForDuration("e", 18, time.Minute); err == nil {
+		t.Errorf("should fail with a text without gaps")
+	}
+}
+
+func TestWPMToFit(t *testing.T) {
+	text := "paris km"
+	codes, _ := Encode(text)
+
+	// duration of a known speed
+	budget := TransmissionDuration(codes, 15)
+	wpm, err := WPMToFit(text, budget, DefaultBeepOptions())
+	if err != nil {
+		t.Fatalf("failed to compute the WPM: %s", err)
+	}
+	if math.Abs(wpm-15) > 0.01 {
+		t.Errorf("expected a speed of 15 WPM, but got %f", wpm)
+	}
+
+	// just under the budget, also with Farnsworth timing
+	budget = 10 * time.Second
+	for _, opts := range []BeepOptions{
+		DefaultBeepOptions(),
+		{Hz: 600, WPM: 18, Volume: 1, CharWPM: 18, EffectiveWPM: 9, PreambleDits: 3},
+	} {
+		if wpm, err = WPMToFit(text, budget, opts); err != nil {
+			t.Fatalf("failed to compute the WPM: %s", err)
+		}
+
+		duration := func(wpm float64) (duration time.Duration) {
+			unit, charGap, wordGap := farnsworthTimings(wpm, wpm*float64(opts.EffectiveWPM)/float64(opts.CharWPM))
+			if opts.CharWPM == 0 {
+				unit, charGap, wordGap = farnsworthTimings(wpm, wpm)
+			}
+			signals, _ := opts.scheduleAt(codes, nil, unit, charGap, wordGap)
+			for _, signal := range signals {
+				duration += signal.Duration
+			}
+			return duration
+		}
+		if d := duration(wpm); d > budget || d < budget-10*time.Millisecond {
+			t.Errorf("expected a duration just under %s, but got %s at %f WPM", budget, d, wpm)
+		}
+		if d := duration(wpm * 0.99); d <= budget {
+			t.Errorf("expected slower speeds not to fit in %s, but got %s at %f WPM", budget, d, wpm*0.99)
+		}
+
+		// rounded up for the integer speeds of options, still within the budget
+		rounded := opts
+		rounded.WPM = int(math.Ceil(wpm))
+		if opts.CharWPM > 0 {
+			rounded.CharWPM = rounded.WPM
+			rounded.EffectiveWPM = int(math.Ceil(wpm * float64(opts.EffectiveWPM) / float64(opts.CharWPM)))
+		}
+		signals, _ := rounded.schedule(codes, nil)
+		var d time.Duration
+		for _, signal := range signals {
+			d += signal.Duration
+		}
+		if d > budget {
+			t.Errorf("expected a duration within %s when rounded up, but got %s with %+v", budget, d, rounded)
+		}
+		if d := duration(math.Floor(wpm)); d <= budget {
+			t.Errorf("expected a duration over %s when rounded down, but got %s at %f WPM", budget, d, math.Floor(wpm))
+		}
+	}
+
+	if _, err := WPMToFit(text, time.Nanosecond, DefaultBeepOptions()); err == nil {
+		t.Errorf("should fail with a budget which is too short")
+	}
+	if _, err := WPMToFit(" ", time.Second, DefaultBeepOptions()); err == nil {
+		t.Errorf("should fail with nothing to transmit")
+	}
+}
+
+func TestStartOffsets(t *testing.T) {
+	opts := DefaultBeepOptions()
+	unit := unitDuration(float64(opts.WPM))
+
+	codes := []Code{Space, A, T, Space, Space, E, Space}
+	expected := []time.Duration{
+		0,         // leading space
+		0,         // a
+		8 * unit,  // t, after a (5 units) and a gap between characters
+		11 * unit, // space, after t
+		11 * unit, // consecutive space
+		18 * unit, // e, after a gap between words
+		19 * unit, // trailing space
+	}
+	if offsets := StartOffsets(codes, opts); !reflect.DeepEqual(offsets, expected) {
+		t.Errorf("expected %v, but got %v", expected, offsets)
+	}
+
+	// the last offset plus the last character ('d', 7 units) is the whole duration
+	codes, _ = Encode("hello world")
+	offsets := StartOffsets(codes, opts)
+	if end := offsets[len(offsets)-1] + 7*unit; end != TransmissionDuration(codes, opts.WPM) {
+		t.Errorf("expected to end at %s, but got %s", TransmissionDuration(codes, opts.WPM), end)
+	}
+
+	if offsets := StartOffsets([]Code{None}, opts); offsets != nil {
+		t.Errorf("expected nil for invalid codes, but got %v", offsets)
+	}
+}
+
+func TestMergeOverSplit(t *testing.T) {
+	ms := time.Millisecond
+
+	dit := func(gap time.Duration) TimedElement { return TimedElement{Element: Dit, On: 100 * ms, Gap: gap} }
+	dah := func(gap time.Duration) TimedElement { return TimedElement{Element: Dah, On: 300 * ms, Gap: gap} }
+
+	// "cq" with a false gap in the middle of 'c' ("−• −•")
+	elements := []TimedElement{
+		dah(100 * ms), dit(250 * ms), dah(100 * ms), dit(300 * ms),
+		dah(100 * ms), dah(100 * ms), dit(100 * ms), dah(0),
+	}
+	table, _ := NewCodeTable(map[rune]Code{'c': C, 'q': Q})
+
+	merged := MergeOverSplit(elements, table)
+	if decoded, err := DecodeWithTiming(merged); err != nil || decoded != "cq" {
+		t.Errorf("expected 'cq', but got '%s' (%v)", decoded, err)
+	}
+	if elements[1].Gap != 250*ms {
+		t.Errorf("given elements should not be modified")
+	}
+
+	// with the default table: "0" split as "−−−− −", merged with the previous one
+	elements = []TimedElement{dah(100 * ms), dah(100 * ms), dah(100 * ms), dah(300 * ms), dah(0)}
+	if decoded, _ := DecodeWithTiming(elements); decoded != "" {
+		t.Errorf("over-split characters should not be decodable, but got '%s'", decoded)
+	}
+	merged = MergeOverSplit(elements, DefaultCodeTable())
+	if decoded, err := DecodeWithTiming(merged); err != nil || decoded != "0" {
+		t.Errorf("expected '0', but got '%s' (%v)", decoded, err)
+	}
+
+	// the default table when nil
+	merged = MergeOverSplit(elements, nil)
+	if decoded, err := DecodeWithTiming(merged); err != nil || decoded != "0" {
+		t.Errorf("expected '0' with a nil table, but got '%s' (%v)", decoded, err)
+	}
+
+	// valid characters and word gaps are kept
+	elements = []TimedElement{dah(100 * ms), dit(700 * ms), dah(100 * ms), dit(0)}
+	if merged := MergeOverSplit(elements, table); !reflect.DeepEqual(merged, elements) {
+		t.Errorf("elements should not be merged across a word gap: %v", merged)
+	}
+	if merged := MergeOverSplit(elements, DefaultCodeTable()); !reflect.DeepEqual(merged, elements) {
+		t.Errorf("valid characters should not be merged: %v", merged)
+	}
+}
+
+func TestTimingFingerprint(t *testing.T) {
+	opts := DefaultBeepOptions()
+
+	codes, _ := Encode("sos")
+	fingerprint := TimingFingerprint(codes, opts)
+
+	// identical messages and options
+	same, _ := Encode("SOS")
+	if TimingFingerprint(same, DefaultBeepOptions()) != fingerprint {
+		t.Errorf("identical messages and options should have the same fingerprint")
+	}
+
+	// known value, for stability across runs
+	if fingerprint != 0x44e3a1ad12fe68fc {
+		t.Errorf("fingerprint is not stable: 0x%x", fingerprint)
+	}
+
+	// different messages or options
+	different, _ := Encode("sis")
+	slower := opts
+	slower.WPM = 5
+	quieter := opts
+	quieter.Volume = 0.5
+	resampled := opts
+	resampled.SampleRate = 8000
+	farnsworth := opts
+	farnsworth.CharWPM = 18
+	unramped := opts
+	unramped.Ramp = 0
+	repeated := opts
+	repeated.RepeatEach = 2
+	deeper := opts
+	deeper.BitDepth = BitDepth24
+	for _, f := range []uint64{
+		TimingFingerprint(different, opts),
+		TimingFingerprint(codes, slower),
+		TimingFingerprint(codes, quieter),
+		TimingFingerprint(codes, resampled),
+		TimingFingerprint(codes, farnsworth),
+		TimingFingerprint(codes, unramped),
+		TimingFingerprint(codes, repeated),
+		TimingFingerprint(codes, deeper),
+		TimingFingerprint([]Code{Code("abc")}, opts),
+	} {
+		if f == fingerprint {
+			t.Errorf("different messages or options should have different fingerprints")
+		}
+	}
+
+	// different options of the same value should not collide
+	spaced := opts
+	spaced.LetterDigitGap = 2
+	preambled := opts
+	preambled.PreambleDits = 2
+	if TimingFingerprint(codes, spaced) == TimingFingerprint(codes, preambled) {
+		t.Errorf("options with different fields set should have different fingerprints")
+	}
+	faded := opts
+	faded.MessageFadeIn = 2
+	if TimingFingerprint(codes, faded) == TimingFingerprint(codes, spaced) {
+		t.Errorf("options with different fields set should have different fingerprints")
+	}
+
+	// rounding modes render different numbers of samples
+	rounded := opts
+	rounded.Rounding = RoundNearest
+	roundedEven := opts
+	roundedEven.Rounding = RoundNearestEven
+	if f := TimingFingerprint(codes, rounded); f == fingerprint || f == TimingFingerprint(codes, roundedEven) {
+		t.Errorf("different rounding modes should have different fingerprints")
+	}
+
+	// options rendering the same audio
+	defaulted := opts
+	defaulted.SampleRate, defaulted.RepeatEach, defaulted.BitDepth = 0, 1, BitDepth16
+	if TimingFingerprint(codes, defaulted) != fingerprint {
+		t.Errorf("options rendering the same audio should have the same fingerprint")
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	codes, _ := Encode("E E")
+
+	unit := unitDuration(20)
+	expected := []Signal{
+		{On: true, Duration: unit},
+		{On: false, Duration: 7 * unit},
+		{On: true, Duration: unit},
+	}
+
+	signals := Timeline(codes, 20)
+	if !reflect.DeepEqual(signals, expected) {
+		t.Errorf("expected %v, but got %v", expected, signals)
+	}
+
+	var total time.Duration
+	for i, signal := range signals {
+		if i > 0 && signal.On == signals[i-1].On {
+			t.Errorf("signals should alternate, but got %v", signals)
+		}
+		total += signal.Duration
+	}
+	if total != 9*unit {
+		t.Errorf("expected total duration of %s, but got %s", 9*unit, total)
+	}
+
+	// gaps between durations and characters
+	expected = []Signal{
+		{On: true, Duration: unit},
+		{On: false, Duration: unit},
+		{On: true, Duration: 3 * unit},
+		{On: false, Duration: 3 * unit},
+		{On: true, Duration: unit},
+	}
+	if signals := Timeline([]Code{A, E}, 20); !reflect.DeepEqual(signals, expected) {
+		t.Errorf("expected %v, but got %v", expected, signals)
+	}
+
+	if signals := Timeline([]Code{None}, 20); signals != nil {
+		t.Errorf("expected nil for invalid codes, but got %v", signals)
+	}
+	if signals := Timeline(codes, 0); signals != nil {
+		t.Errorf("expected nil for an invalid speed, but got %v", signals)
+	}
+}
+
+func TestDutyCycle(t *testing.T) {
+	opts := DefaultBeepOptions()
+
+	// "sos": 3 + 9 + 3 units on, in 5 + 3 + 11 + 3 + 5 units
+	if duty := DutyCycle([]Code{S, O, S}, opts); math.Abs(duty-15.0/27.0) > 1e-9 {
+		t.Errorf("expected duty cycle of %f, but got %f", 15.0/27.0, duty)
+	}
+
+	// "e e": 2 units on, in 1 + 7 + 1 units (trailing gaps are not counted)
+	if duty := DutyCycle([]Code{E, Space, E, Space}, opts); math.Abs(duty-2.0/9.0) > 1e-9 {
+		t.Errorf("expected duty cycle of %f, but got %f", 2.0/9.0, duty)
+	}
+
+	// longer gaps with Farnsworth timing
+	opts.CharWPM, opts.EffectiveWPM = 20, 5
+	if duty := DutyCycle([]Code{S, O, S}, opts); duty >= 15.0/27.0 {
+		t.Errorf("expected duty cycle lower than %f with Farnsworth timing, but got %f", 15.0/27.0, duty)
+	}
+
+	if duty := DutyCycle([]Code{Space}, opts); duty != 0 {
+		t.Errorf("expected 0 without tones, but got %f", duty)
+	}
+	if duty := DutyCycle([]Code{None}, opts); duty != 0 {
+		t.Errorf("expected 0 for invalid codes, but got %f", duty)
+	}
+}
+
+func TestElementTimings(t *testing.T) {
+	opts := DefaultBeepOptions()
+	unit, _, wordGap := opts.timings()
+
+	expected := []TimedElement{
+		{Element: Dit, On: unit, Gap: unit},
+		{Element: Dah, On: 3 * unit, Gap: wordGap},
+		{Element: Dah, On: 3 * unit, Gap: 0},
+	}
+	if elements := ElementTimings([]Code{A, Space, T}, opts); !reflect.DeepEqual(elements, expected) {
+		t.Errorf("expected elements %v, but got %v", expected, elements)
+	}
+
+	if elements := ElementTimings([]Code{A}, opts); len(elements) != 2 || elements[0].Element != Dit || elements[1].Element != Dah {
+		t.Errorf("expected a dit and a dah for 'a', but got %v", elements)
+	}
+
+	if elements := ElementTimings([]Code{Code("abc")}, opts); elements != nil {
+		t.Errorf("should return nil for invalid codes, but got %v", elements)
+	}
+}
+
+func TestToRhythm(t *testing.T) {
+	expected := []RhythmNote{
+		{Rest: false, Beats: 1},
+		{Rest: true, Beats: 1},
+		{Rest: false, Beats: 3},
+		{Rest: true, Beats: 7},
+		{Rest: false, Beats: 3},
+	}
+	if notes := ToRhythm([]Code{A, Space, T}, DefaultBeepOptions()); !reflect.DeepEqual(notes, expected) {
+		t.Errorf("expected notes %v, but got %v", expected, notes)
+	}
+
+	// in the same order and proportions as the timeline, with Farnsworth timing
+	opts := BeepOptions{Hz: 600, WPM: 18, Volume: 1, SampleRate: 8000, CharWPM: 18, EffectiveWPM: 10}
+	codes, _ := Encode("paris km")
+	signals, notes := TimelineWith(codes, opts), ToRhythm(codes, opts)
+	if len(notes) != len(signals) {
+		t.Fatalf("expected %d notes, but got %d", len(signals), len(notes))
+	}
+	unit, _, _ := opts.timings()
+	for i, note := range notes {
+		if note.Rest == signals[i].On || math.Abs(note.Beats*float64(unit)-float64(signals[i].Duration)) > 1 {
+			t.Errorf("note %d does not match the timeline: %v / %v", i, note, signals[i])
+		}
+	}
+
+	// dits are shorter notes than dahs
+	notes = ToRhythm([]Code{E, T}, opts)
+	if dit, dah := notes[0], notes[2]; dit.Rest || dah.Rest || dit.Beats >= dah.Beats {
+		t.Errorf("dits should be shorter notes than dahs: %v / %v", dit, dah)
+	}
+
+	if notes := ToRhythm([]Code{Code("abc")}, opts); notes != nil {
+		t.Errorf("should return nil for invalid codes, but got %v", notes)
+	}
+}
+
+func TestTimelineWithDutyCycle(t *testing.T) {
+	opts := DefaultBeepOptions()
+	codes, _ := Encode("cq 0 0 0 0 test")
+	original := TimelineWith(codes, opts)
+
+	maxDuty, window := 0.6, 5*time.Second
+	signals, err := TimelineWithDutyCycle(codes, maxDuty, window, opts)
+	if err != nil {
+		t.Fatalf("failed to enforce the duty cycle: %s", err)
+	}
+
+	// only gaps between words are stretched
+	if len(signals) != len(original) {
+		t.Fatalf("expected %d signals, but got %d", len(original), len(signals))
+	}
+	_, _, wordGap := opts.timings()
+	stretched := 0
+	for i, signal := range signals {
+		if signal == original[i] {
+			continue
+		}
+		if signal.On || original[i].Duration != wordGap || signal.Duration < wordGap {
+			t.Errorf("expected only gaps between words stretched, but signal %d changed from %v to %v", i, original[i], signal)
+		}
+		stretched++
+	}
+	if stretched == 0 {
+		t.Errorf("expected pauses inserted, but got %v", signals)
+	}
+
+	// the text is not changed
+	unit, _, _ := opts.timings()
+	events := make([]KeyEvent, len(signals))
+	for i, signal := range signals {
+		events[i] = KeyEvent{Down: signal.On, Duration: signal.Duration}
+	}
+	if keyed, err := KeyTimingsToCodes(events, unit); err != nil {
+		t.Errorf("failed to convert the timeline: %s", err)
+	} else if decoded, _ := Decode(keyed); decoded != "cq 0 0 0 0 test" {
+		t.Errorf("text should not be changed, but got '%s'", decoded)
+	}
+
+	// on-time of tones in sliding windows, starting at each of the signals
+	for i := range signals {
+		var elapsed, on time.Duration
+		for _, signal := range signals[i:] {
+			if elapsed >= window {
+				break
+			}
+			if signal.On {
+				on += min(signal.Duration, window-elapsed)
+			}
+			elapsed += signal.Duration
+		}
+		if duty := float64(on) / float64(window); duty > maxDuty {
+			t.Errorf("duty cycle of the window at signal %d exceeds %f: %f", i, maxDuty, duty)
+		}
+	}
+
+	// no pauses are needed
+	if signals, err := TimelineWithDutyCycle(codes, 1, window, opts); err != nil || !reflect.DeepEqual(signals, original) {
+		t.Errorf("expected the timeline not changed, but got %v (%v)", signals, err)
+	}
+
+	// not possible with pauses, as there is no gap between words in "0000"
+	for _, text := range []string{"cq 0 0 0 0 test", "0000"} {
+		codes, _ := Encode(text)
+		if _, err := TimelineWithDutyCycle(codes, 0.1, window, opts); err == nil {
+			t.Errorf("should fail with a limit which cannot be kept for '%s'", text)
+		}
+	}
+	words, _ := Encode("0000")
+	if _, err := TimelineWithDutyCycle(words, maxDuty, window, opts); err == nil {
+		t.Errorf("should fail without gaps between words to stretch")
+	}
+
+	// invalid arguments
+	if _, err := TimelineWithDutyCycle(codes, 0, window, opts); err == nil {
+		t.Errorf("should fail with a zero duty cycle")
+	}
+	if _, err := TimelineWithDutyCycle(codes, maxDuty, 0, opts); err == nil {
+		t.Errorf("should fail with a zero window")
+	}
+}
+
+func TestLetterDigitGap(t *testing.T) {
+	opts := DefaultBeepOptions()
+	opts.LetterDigitGap = 2
+	unit := unitDuration(float64(opts.WPM))
+
+	// gaps between characters (and words) in order
+	gaps := func(codes []Code, opts BeepOptions) (gaps []time.Duration) {
+		for _, signal := range TimelineWith(codes, opts) {
+			if !signal.On && signal.Duration > unit {
+				gaps = append(gaps, signal.Duration)
+			}
+		}
+		return gaps
+	}
+
+	codes, _ := Encode("ab12 c3")
+	expected := []time.Duration{
+		3 * unit, // a, b
+		5 * unit, // b, 1: extra gap at the transition
+		3 * unit, // 1, 2
+		7 * unit, // word gap
+		5 * unit, // c, 3
+	}
+	if got := gaps(codes, opts); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected gaps %v, but got %v", expected, got)
+	}
+
+	// also in the rendered samples
+	if samples, expected := len(Samples(codes, opts)), len(Samples(codes, DefaultBeepOptions()))+2*beep.SampleRate(opts.SampleRate).N(2*unit); samples != expected {
+		t.Errorf("expected %d samples, but got %d", expected, samples)
+	}
+
+	// no extra gaps by default
+	for _, gap := range gaps(codes, DefaultBeepOptions()) {
+		if gap == 5*unit {
+			t.Errorf("no extra gaps are expected by default, but got %v", gaps(codes, DefaultBeepOptions()))
+			break
+		}
+	}
+
+	opts.LetterDigitGap = -1
+	if signals := TimelineWith(codes, opts); signals != nil {
+		t.Errorf("expected nil for invalid options, but got %v", signals)
+	}
+}
+
+func TestCallsignGap(t *testing.T) {
+	opts := DefaultBeepOptions()
+	opts.Callsign = "DL1IA"
+	unit := unitDuration(float64(opts.WPM))
+
+	// gaps between characters (and words) in order
+	var gaps []time.Duration
+	codes, _ := Encode("cq de dl1ia k")
+	for _, signal := range TimelineWith(codes, opts) {
+		if !signal.On && signal.Duration > unit {
+			gaps = append(gaps, signal.Duration)
+		}
+	}
+
+	expected := []time.Duration{
+		3 * unit, 7 * unit, // cq
+		3 * unit, 7 * unit, // de
+		2 * unit, 2 * unit, 2 * unit, 2 * unit, 7 * unit, // dl1ia, tighter
+	}
+	if !reflect.DeepEqual(gaps, expected) {
+		t.Errorf("expected gaps %v, but got %v", expected, gaps)
+	}
+
+	// offsets follow the tighter gaps (after 'd' of 7 units)
+	offsets := StartOffsets(codes, opts)
+	if offsets[7]-offsets[6] != (7+2)*unit {
+		t.Errorf("expected 'l' right after 'd' with a tighter gap, but got %s -> %s", offsets[6], offsets[7])
+	}
+
+	// invalid callsigns
+	for _, callsign := range []string{"DL1IA K", "DL#1"} {
+		opts.Callsign = callsign
+		if signals := TimelineWith(codes, opts); signals != nil {
+			t.Errorf("expected nil for an invalid callsign '%s', but got %v", callsign, signals)
+		}
+	}
+}
+
+func TestPreambleDits(t *testing.T) {
+	opts := DefaultBeepOptions()
+	opts.PreambleDits = 3
+	unit, charGap, wordGap := opts.timings()
+
+	codes := []Code{T}
+	expected := []Signal{
+		{On: true, Duration: unit}, {On: false, Duration: charGap},
+		{On: true, Duration: unit}, {On: false, Duration: charGap},
+		{On: true, Duration: unit}, {On: false, Duration: wordGap},
+		{On: true, Duration: 3 * unit}, // t
+	}
+	if signals := TimelineWith(codes, opts); !reflect.DeepEqual(signals, expected) {
+		t.Errorf("expected %v, but got %v", expected, signals)
+	}
+
+	// the first character starts after the preamble, in the stream too
+	start := 3*unit + 2*charGap + wordGap
+	if offsets := StartOffsets(codes, opts); offsets[0] != start {
+		t.Errorf("expected the first character at %s, but got %s", start, offsets[0])
+	}
+	samples := Samples(codes, opts)
+	sr := beep.SampleRate(opts.SampleRate)
+	for _, at := range []time.Duration{unit / 2, unit + charGap + unit/2, start - wordGap/2, start + unit} {
+		on := false
+		for _, sample := range samples[sr.N(at)-20 : sr.N(at)+20] {
+			on = on || math.Abs(sample[0]) > 0.5
+		}
+		if expected := at != start-wordGap/2; on != expected {
+			t.Errorf("expected tone %t at %s of the stream", expected, at)
+		}
+	}
+
+	// no preamble without characters
+	if signals := TimelineWith([]Code{Space}, opts); len(signals) != 0 {
+		t.Errorf("expected no signals without characters, but got %v", signals)
+	}
+
+	opts.PreambleDits = -1
+	if signals := TimelineWith(codes, opts); signals != nil {
+		t.Errorf("expected nil for invalid options, but got %v", signals)
+	}
+}
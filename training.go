@@ -0,0 +1,412 @@
+package morse
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"time"
+	"unicode"
+
+	"github.com/faiface/beep"
+)
+
+// Mnemonics for memorizing codes of characters
+//
+// Each syllable stands for a duration: capitalized ones for dahs, and others for dits.
+var Mnemonics = map[rune]string{
+	'a': "a-PART",
+	'b': "BOT-tle-of-beer",
+	'c': "CO-ca-CO-la",
+	'd': "DOG-did-it",
+	'e': "eh",
+	'f': "fee-fie-FOE-fum",
+	'g': "GOOD-GRA-vy",
+	'h': "hip-pi-ty-hop",
+	'i': "i-vy",
+	'j': "a-WHOLE-LOT-MORE",
+	'k': "KAN-ga-ROO",
+	'l': "lu-NAR-i-ty",
+	'm': "MOO-MOO",
+	'n': "NA-vy",
+	'o': "OH-MY-GOSH",
+	'p': "a-POP-CORN-bag",
+	'q': "GOD-SAVE-the-QUEEN",
+	'r': "ro-TA-tion",
+	's': "sa-la-mi",
+	't': "TALL",
+	'u': "u-ni-FORM",
+	'v': "vic-to-ry-VEE",
+	'w': "the-WHITE-WHALE",
+	'x': "X-marks-the-SPOT",
+	'y': "YOU'RE-a-NICE-GUY",
+	'z': "ZEE-BRA-cross-ing",
+}
+
+// Mnemonic returns the mnemonic of given character `chr` from `Mnemonics`.
+func Mnemonic(chr rune) (mnemonic string, exists bool) {
+	mnemonic, exists = Mnemonics[unicode.ToLower(chr)]
+	return mnemonic, exists
+}
+
+// Flashcard for learning a character
+type Flashcard struct {
+	Char       rune
+	Code       string // code in ASCII dots and dashes, empty when there is no code for the character
+	Mnemonic   string // empty when there is no mnemonic for the character
+	Difficulty int
+}
+
+// Flashcards returns a deck of flashcards, one for each of given `chars`.
+func Flashcards(chars []rune) (cards []Flashcard) {
+	cards = []Flashcard{}
+
+	for _, chr := range chars {
+		card := Flashcard{
+			Char:       chr,
+			Difficulty: Difficulty(chr),
+		}
+		if code, err := charToCode(unicode.ToLower(chr)); err == nil && code != Space {
+			card.Code = code.String()
+		}
+		if mnemonic, exists := Mnemonic(chr); exists {
+			card.Mnemonic = mnemonic
+		}
+
+		cards = append(cards, card)
+	}
+
+	return cards
+}
+
+// Level for levels of learners
+type Level int
+
+// Levels of learners
+const (
+	LevelBeginner     Level = iota // learning characters
+	LevelIntermediate              // copying words
+	LevelAdvanced                  // copying conversations
+	LevelExpert                    // copying in the head at contest speeds
+)
+
+// recommended speeds for each level
+var recommendedWPMs = map[Level]float64{
+	LevelBeginner:     5,
+	LevelIntermediate: 13,
+	LevelAdvanced:     20,
+	LevelExpert:       30,
+}
+
+// RecommendWPM returns a recommended sending speed (in WPM) for learners of given `level`.
+//
+// Returns 0 for an unknown level.
+func RecommendWPM(level Level) float64 {
+	return recommendedWPMs[level]
+}
+
+// bounds of recommended practice sessions
+const (
+	sessionSlowWPM    = 5  // speed of the longest sessions, or slower
+	sessionFastWPM    = 35 // speed of the shortest sessions, or faster
+	sessionMaxMinutes = 30
+	sessionMinMinutes = 10
+)
+
+// RecommendedSessionLength returns a recommended maximum length of a practice session at given `wpm`,
+// before fatigue starts to hurt the accuracy.
+//
+// Faster speeds demand more concentration, so sessions get shorter linearly from 30 minutes (at 5 WPM or slower)
+// to 10 minutes (at 35 WPM or faster). Returns 0 when `wpm` is not positive.
+func RecommendedSessionLength(wpm float64) time.Duration {
+	if wpm <= 0 {
+		return 0
+	}
+
+	ratio := (min(max(wpm, sessionSlowWPM), sessionFastWPM) - sessionSlowWPM) / (sessionFastWPM - sessionSlowWPM)
+	minutes := sessionMaxMinutes - ratio*(sessionMaxMinutes-sessionMinMinutes)
+
+	return time.Duration(math.Round(minutes * float64(time.Minute)))
+}
+
+// Difficulty returns a heuristic difficulty of learning given character `chr`.
+//
+// Longer codes, and codes which alternate between dits and dahs more often, are rated as more difficult.
+// Returns 0 for characters without a code (including space).
+func Difficulty(chr rune) int {
+	code, err := charToCode(unicode.ToLower(chr))
+	if err != nil || code == Space {
+		return 0
+	}
+
+	durations := []rune(code)
+
+	alternations := 0
+	for i := 1; i < len(durations); i++ {
+		if durations[i] != durations[i-1] {
+			alternations++
+		}
+	}
+
+	return len(durations) + alternations
+}
+
+// ListeningTest picks a random character from `charset` with `random`,
+// and returns a stream of its sound (with the default options at 44100 Hz) and the character as the answer.
+//
+// Returns nil and 0 when there is no encodable character in `charset`.
+func ListeningTest(random *rand.Rand, charset string) (stream beep.Streamer, answer rune) {
+	return ListeningTestWith(random, charset, DefaultBeepOptions())
+}
+
+// ListeningTestWith picks a random character from `charset` with `random` just like `ListeningTest`,
+// and returns a stream of its sound with given `opts` (eg. repeated with `RepeatEach`) and the character as the answer.
+//
+// Returns nil and 0 when there is no encodable character in `charset`, or `opts` are not valid.
+func ListeningTestWith(random *rand.Rand, charset string, opts BeepOptions) (stream beep.Streamer, answer rune) {
+	if err := opts.validate(); err != nil {
+		return nil, 0
+	}
+
+	candidates := []rune{}
+	for _, chr := range charset {
+		if code, err := charToCode(unicode.ToLower(chr)); err == nil && code != Space {
+			candidates = append(candidates, chr)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, 0
+	}
+
+	answer = candidates[randomOrNew(random).Intn(len(candidates))]
+
+	code, _ := charToCode(unicode.ToLower(answer))
+	stream, _ = streamCodes([]Code{code}, opts.sampleRate(), opts)
+
+	return stream, answer
+}
+
+// Quiz is a listening quiz which plays a random character, and reveals it after a delay for copying it
+type Quiz struct {
+	Random  *rand.Rand  // source of picking characters (a time-seeded one is used when nil)
+	Charset string      // characters to be quizzed
+	Options BeepOptions // options for playing characters (eg. repeated with `RepeatEach`)
+
+	AnswerDelay time.Duration     // delay between the end of the prompt and the answer
+	OnAnswer    func(answer rune) // called with the answer after `AnswerDelay` (not called when nil)
+}
+
+// Play picks a random character from `Charset` just like `ListeningTestWith`, and plays it synchronously,
+// then waits for `AnswerDelay` and calls `OnAnswer` with it, and returns it.
+//
+// When `ctx` is canceled during the prompt or the delay, `OnAnswer` is not called, and `ctx.Err()` is returned.
+//
+// Will return an error when there is no encodable character in `Charset`, `Options` are not valid, or `AnswerDelay` is negative.
+func (q *Quiz) Play(ctx context.Context) (answer rune, err error) {
+	if err = q.Options.validate(); err != nil {
+		return 0, err
+	}
+	if q.AnswerDelay < 0 {
+		return 0, fmt.Errorf("answer delay should not be negative: %s", q.AnswerDelay)
+	}
+
+	var stream beep.Streamer
+	if stream, answer = ListeningTestWith(q.Random, q.Charset, q.Options); stream == nil {
+		return 0, fmt.Errorf("no encodable character in charset: '%s'", q.Charset)
+	}
+
+	if err = playContext(ctx, stream, q.Options); err != nil {
+		return 0, err
+	}
+
+	timer := time.NewTimer(q.AnswerDelay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-timer.C:
+	}
+
+	if q.OnAnswer != nil {
+		q.OnAnswer(answer)
+	}
+
+	return answer, nil
+}
+
+// PracticeResult is a score of a character keyed back in a `PracticeSession`
+type PracticeResult struct {
+	Expected rune   // character which was played
+	Keyed    []Code // codes which were keyed back
+
+	// largest deviation of the keyed tones and gaps in characters from their standard durations, relative to them
+	Deviation float64
+
+	Correct bool // whether `Keyed` is the code of `Expected` alone, with `Deviation` within the tolerance
+}
+
+// PracticeSession is a drill which plays a random character, and scores the user's reproduction of it keyed back
+type PracticeSession struct {
+	random     *rand.Rand
+	candidates []rune
+	opts       BeepOptions   // options for playing characters, at the copy speed
+	sendUnit   time.Duration // duration of a unit at the send speed, for scoring keyed ones
+	tolerance  float64
+
+	keyer  *Keyer
+	events chan Event
+
+	expected rune       // character being practiced, 0 when none
+	keys     []KeyEvent // key events fed for the character
+	keyed    []Code     // codes completed by the keyer
+}
+
+// NewPracticeSession creates a new `PracticeSession` which picks characters from `charset` with `random`,
+// plays them with `opts`, and accepts keyed ones whose durations deviate from the standard ones within `tolerance`
+// (relative to them, eg. 0.25 for dits of 0.75 ~ 1.25 units).
+//
+// Will return an error when there is no encodable character in `charset`, `opts` are not valid, or `tolerance` is negative.
+func NewPracticeSession(random *rand.Rand, charset string, opts BeepOptions, tolerance float64) (session *PracticeSession, err error) {
+	charWPM, _ := opts.farnsworthWPMs()
+
+	return NewPracticeSessionWithSpeeds(random, charset, opts, charWPM, tolerance)
+}
+
+// NewPracticeSessionWithSpeeds creates a new `PracticeSession` just like `NewPracticeSession`,
+// but keyed characters are expected and scored at `sendWPM`, separately from the copy speed of `opts` they are played at.
+//
+// Will return an error when there is no encodable character in `charset`, `opts` are not valid,
+// `sendWPM` is not positive, or `tolerance` is negative.
+func NewPracticeSessionWithSpeeds(random *rand.Rand, charset string, opts BeepOptions, sendWPM int, tolerance float64) (session *PracticeSession, err error) {
+	if err = opts.validate(); err != nil {
+		return nil, err
+	}
+	if sendWPM <= 0 {
+		return nil, fmt.Errorf("send WPM should be positive: %d", sendWPM)
+	}
+	if tolerance < 0 {
+		return nil, fmt.Errorf("tolerance should not be negative: %f", tolerance)
+	}
+
+	candidates := []rune{}
+	for _, chr := range charset {
+		if code, err := charToCode(unicode.ToLower(chr)); err == nil && code != Space {
+			candidates = append(candidates, chr)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no encodable character in charset: '%s'", charset)
+	}
+
+	// buffered for the events of a single key event (or a flush), which are drained after each of them
+	events := make(chan Event, 2)
+
+	sendUnit := unitDuration(float64(sendWPM))
+
+	var keyer *Keyer
+	if keyer, err = NewKeyer(sendUnit, events); err != nil {
+		return nil, err
+	}
+
+	return &PracticeSession{
+		random:     randomOrNew(random),
+		candidates: candidates,
+		opts:       opts,
+		sendUnit:   sendUnit,
+		tolerance:  tolerance,
+		keyer:      keyer,
+		events:     events,
+	}, nil
+}
+
+// Next picks a random character and plays it synchronously, then returns it.
+//
+// Key events fed after it (with `Key`) are scored for the character with `Score`.
+func (s *PracticeSession) Next() (chr rune, err error) {
+	s.keyer.Flush()
+	s.drain()
+
+	chr = s.candidates[s.random.Intn(len(s.candidates))]
+	s.expected, s.keys, s.keyed = chr, []KeyEvent{}, []Code{}
+
+	code, _ := charToCode(unicode.ToLower(chr))
+	if err = beepContext(context.Background(), []Code{code}, s.opts); err != nil {
+		return 0, err
+	}
+
+	return chr, nil
+}
+
+// Key feeds given key event `e` of the user's reproduction.
+//
+// Will return an error when no character is being practiced (see `Next`), or the duration of `e` is not positive.
+func (s *PracticeSession) Key(e KeyEvent) (err error) {
+	if s.expected == 0 {
+		return fmt.Errorf("no character is being practiced")
+	}
+
+	if err = s.keyer.Key(e); err != nil {
+		return err
+	}
+	s.keys = append(s.keys, e)
+	s.drain()
+
+	return nil
+}
+
+// Score completes the user's reproduction of the current character, and returns its result.
+//
+// The session waits for the next character (see `Next`) after it.
+func (s *PracticeSession) Score() (result PracticeResult) {
+	s.keyer.Flush()
+	s.drain()
+
+	result = PracticeResult{
+		Expected: s.expected,
+		Keyed:    s.keyed,
+	}
+
+	joined, _ := joinEvents(s.keys)
+	for _, e := range joined {
+		units := float64(e.Duration) / float64(s.sendUnit)
+
+		// gaps between characters or words are not scored
+		standard := float64(unitsIntraGap)
+		if e.Down {
+			standard = unitsDit
+			if units >= thresholdDah {
+				standard = unitsDah
+			}
+		} else if units >= thresholdCharGap {
+			continue
+		}
+
+		result.Deviation = math.Max(result.Deviation, math.Abs(units-standard)/standard)
+	}
+
+	code, err := charToCode(unicode.ToLower(s.expected))
+	result.Correct = err == nil && slices.Equal(s.keyed, []Code{code}) && result.Deviation <= s.tolerance
+
+	s.expected, s.keys, s.keyed = 0, []KeyEvent{}, []Code{}
+
+	return result
+}
+
+// collects codes from the events of the keyer
+func (s *PracticeSession) drain() {
+	for {
+		select {
+		case e := <-s.events:
+			switch e.Type {
+			case EventCharComplete:
+				s.keyed = append(s.keyed, e.Code)
+			case EventWordComplete:
+				s.keyed = append(s.keyed, Space)
+			}
+		default:
+			return
+		}
+	}
+}
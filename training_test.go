@@ -0,0 +1,418 @@
+package morse
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/faiface/beep"
+)
+
+func TestDifficulty(t *testing.T) {
+	// E (•) is easier than long or alternating codes
+	for _, chr := range []rune{'1', '9', 'c', 'Q'} {
+		if Difficulty('E') >= Difficulty(chr) {
+			t.Errorf("'E' should be easier than '%c': %d / %d", chr, Difficulty('E'), Difficulty(chr))
+		}
+	}
+
+	// alternation makes codes more difficult
+	if Difficulty('r') <= Difficulty('s') {
+		t.Errorf("'r' should be more difficult than 's': %d / %d", Difficulty('r'), Difficulty('s'))
+	}
+
+	// no difficulty for characters without codes
+	for _, chr := range []rune{' ', '&', '가'} {
+		if difficulty := Difficulty(chr); difficulty != 0 {
+			t.Errorf("difficulty of '%c' should be 0, but got %d", chr, difficulty)
+		}
+	}
+}
+
+func TestFlashcards(t *testing.T) {
+	chars := []rune{'A', 'e', '5', '&'}
+
+	cards := Flashcards(chars)
+	if len(cards) != len(chars) {
+		t.Fatalf("expected %d cards, but got %d", len(chars), len(cards))
+	}
+
+	expected := []Flashcard{
+		{Char: 'A', Code: ".-", Mnemonic: "a-PART", Difficulty: 3},
+		{Char: 'e', Code: ".", Mnemonic: "eh", Difficulty: 1},
+		{Char: '5', Code: ".....", Difficulty: 5},
+		{Char: '&', Code: "", Difficulty: 0},
+	}
+	for i, card := range cards {
+		if card != expected[i] {
+			t.Errorf("expected card %+v, but got %+v", expected[i], card)
+		}
+	}
+}
+
+func TestMnemonic(t *testing.T) {
+	if mnemonic, exists := Mnemonic('A'); !exists || mnemonic != "a-PART" {
+		t.Errorf("unexpected mnemonic for 'A': %s", mnemonic)
+	}
+
+	if _, exists := Mnemonic('1'); exists {
+		t.Errorf("there should be no mnemonic for '1'")
+	}
+
+	// syllables should match durations
+	for chr, mnemonic := range Mnemonics {
+		durations := []Duration{}
+		for _, syllable := range strings.Split(mnemonic, "-") {
+			if syllable == strings.ToUpper(syllable) {
+				durations = append(durations, Dah)
+			} else {
+				durations = append(durations, Dit)
+			}
+		}
+
+		if code := CodeFromDurations(durations...); code != codesMap[chr] {
+			t.Errorf("mnemonic of '%c' does not match its code: %s / %s", chr, mnemonic, codesMap[chr])
+		}
+	}
+}
+
+// decodes codes from given stream of sounds in the standard timing
+func codesFromStream(t *testing.T, stream beep.Streamer, sr beep.SampleRate, opts BeepOptions) []Code {
+	samplesPerUnit := sr.N(unitDuration(float64(opts.WPM)))
+
+	units := []bool{}
+	buf := make([][2]float64, samplesPerUnit)
+	for {
+		n, ok := stream.Stream(buf)
+		if n > 0 {
+			peak := 0.0
+			for _, sample := range buf[:n] {
+				peak = math.Max(peak, math.Abs(sample[0]))
+			}
+			units = append(units, peak > 0.1)
+		}
+		if !ok || n < len(buf) {
+			break
+		}
+	}
+
+	codes, err := codesFromUnits(units)
+	if err != nil {
+		t.Fatalf("failed to decode codes from stream: %s", err)
+	}
+	return codes
+}
+
+func TestListeningTest(t *testing.T) {
+	random := rand.New(rand.NewSource(42))
+
+	for i := 0; i < 10; i++ {
+		stream, answer := ListeningTest(random, "KMRSU&")
+		if stream == nil || !strings.ContainsRune("KMRSU", answer) {
+			t.Fatalf("unexpected answer: '%c'", answer)
+		}
+
+		codes := codesFromStream(t, stream, beep.SampleRate(44100), DefaultBeepOptions())
+		if decoded, err := Decode(codes); err != nil || decoded != strings.ToLower(string(answer)) {
+			t.Errorf("stream does not match the answer '%c': %s (%v)", answer, decoded, err)
+		}
+	}
+
+	if stream, answer := ListeningTest(random, "& "); stream != nil || answer != 0 {
+		t.Errorf("there should be no listening test for non-encodable characters")
+	}
+}
+
+func TestListeningTestWith(t *testing.T) {
+	random := rand.New(rand.NewSource(42))
+
+	opts := DefaultBeepOptions()
+	opts.RepeatEach = 3
+
+	for i := 0; i < 5; i++ {
+		stream, answer := ListeningTestWith(random, "KMRSU", opts)
+		if stream == nil {
+			t.Fatalf("unexpected answer: '%c'", answer)
+		}
+
+		// the character is repeated 3 times
+		codes := codesFromStream(t, stream, beep.SampleRate(44100), opts)
+		code := codesMap[unicode.ToLower(answer)]
+		if expected := []Code{code, code, code}; !reflect.DeepEqual(codes, expected) {
+			t.Errorf("expected %v for '%c', but got %v", expected, answer, codes)
+		}
+	}
+
+	opts.RepeatEach = -1
+	if stream, answer := ListeningTestWith(random, "KMRSU", opts); stream != nil || answer != 0 {
+		t.Errorf("there should be no listening test with invalid options")
+	}
+}
+
+func TestRepeatEach(t *testing.T) {
+	opts := DefaultBeepOptions()
+	opts.RepeatEach = 2
+	unit, _, _ := opts.timings()
+
+	// tones and silences back to key events
+	codes, _ := Encode("ab c")
+	events := []KeyEvent{}
+	for _, signal := range TimelineWith(codes, opts) {
+		events = append(events, KeyEvent{Down: signal.On, Duration: signal.Duration})
+	}
+
+	repeated, err := KeyTimingsToCodes(events, unit)
+	if err != nil {
+		t.Fatalf("failed to convert timeline: %s", err)
+	}
+	if decoded, err := Decode(repeated); err != nil || decoded != "aabb cc" {
+		t.Errorf("expected each character twice ('aabb cc'), but got '%s' (%v)", decoded, err)
+	}
+
+	// offsets of characters are where their first ones start (after 'a' of 5 units, twice)
+	offsets := StartOffsets(codes, opts)
+	if offsets[1] != (2*5+2*3)*unit {
+		t.Errorf("expected 'b' after 'a' sent twice, but got %s", offsets[1])
+	}
+}
+
+func TestQuiz(t *testing.T) {
+	played, restore := fakeSpeaker(func(sampleRate beep.SampleRate, bufferSize int) error { return nil })
+	defer restore()
+
+	delay := 50 * time.Millisecond
+
+	var answered rune
+	var elapsed time.Duration
+	var start time.Time
+
+	quiz := Quiz{
+		Random:      rand.New(rand.NewSource(42)),
+		Charset:     "KMRSU",
+		Options:     DefaultBeepOptions(),
+		AnswerDelay: delay,
+		OnAnswer: func(answer rune) {
+			answered, elapsed = answer, time.Since(start)
+		},
+	}
+
+	// answer is revealed after the prompt and the delay
+	start = time.Now()
+	answer, err := quiz.Play(context.Background())
+	if err != nil {
+		t.Fatalf("failed to play a quiz: %s", err)
+	}
+	if answered != answer || !strings.ContainsRune(quiz.Charset, answer) {
+		t.Errorf("expected the callback with '%c', but got '%c'", answer, answered)
+	}
+	if elapsed < delay {
+		t.Errorf("expected the callback after %s, but it was called after %s", delay, elapsed)
+	}
+	code, _ := charToCode(unicode.ToLower(answer))
+	if expected := Samples([]Code{code}, quiz.Options); !reflect.DeepEqual(*played, expected) {
+		t.Errorf("expected '%c' played, but played %d samples", answer, len(*played))
+	}
+
+	// no answer when canceled during the delay
+	answered = 0
+	quiz.AnswerDelay = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), delay)
+	defer cancel()
+	if _, err := quiz.Play(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected the deadline exceeded, but got %v", err)
+	}
+	if answered != 0 {
+		t.Errorf("callback should not be called when canceled, but got '%c'", answered)
+	}
+
+	quiz.AnswerDelay = -time.Second
+	if _, err := quiz.Play(context.Background()); err == nil {
+		t.Errorf("should fail with a negative answer delay")
+	}
+	quiz.AnswerDelay, quiz.Charset = 0, "~"
+	if _, err := quiz.Play(context.Background()); err == nil {
+		t.Errorf("should fail with no encodable characters")
+	}
+}
+
+func TestPracticeSession(t *testing.T) {
+	played, restore := fakeSpeaker(func(sampleRate beep.SampleRate, bufferSize int) error { return nil })
+	defer restore()
+
+	opts := DefaultBeepOptions()
+	unit, _, _ := opts.timings()
+
+	session, err := NewPracticeSession(rand.New(rand.NewSource(42)), "KMRSU", opts, 0.3)
+	if err != nil {
+		t.Fatalf("failed to create a session: %s", err)
+	}
+
+	if err := session.Key(KeyEvent{Down: true, Duration: unit}); err == nil {
+		t.Errorf("should fail to key before a character is played")
+	}
+
+	// keys given `code` with tones and gaps scaled by `jitter`, or dits and dahs swapped when `swapped`
+	key := func(code Code, jitter float64, swapped bool) {
+		for i, chr := range code {
+			if i > 0 {
+				_ = session.Key(KeyEvent{Down: false, Duration: time.Duration(float64(unit) * jitter)})
+			}
+
+			units := unitsDit
+			if (chr == dahRune) != swapped {
+				units = unitsDah
+			}
+			if err := session.Key(KeyEvent{Down: true, Duration: time.Duration(float64(unit) * float64(units) * jitter)}); err != nil {
+				t.Fatalf("failed to key: %s", err)
+			}
+		}
+	}
+
+	for _, test := range []struct {
+		jitter   float64
+		swapped  bool
+		matches  bool
+		expected bool
+	}{
+		{1.0, false, true, true},  // exact
+		{1.15, false, true, true}, // within the tolerance
+		{1.0, true, false, false}, // wrong elements
+		{1.6, false, true, false}, // right elements, but too sloppy
+	} {
+		*played = [][2]float64{}
+
+		chr, err := session.Next()
+		if err != nil {
+			t.Fatalf("failed to play: %s", err)
+		}
+		code, _ := charToCode(unicode.ToLower(chr))
+		if expected := Samples([]Code{code}, opts); !reflect.DeepEqual(*played, expected) {
+			t.Errorf("expected '%c' played, but played %d samples", chr, len(*played))
+		}
+
+		key(code, test.jitter, test.swapped)
+		result := session.Score()
+
+		if result.Expected != chr {
+			t.Errorf("expected a result for '%c', but got '%c'", chr, result.Expected)
+		}
+		if matches := reflect.DeepEqual(result.Keyed, []Code{code}); matches != test.matches {
+			t.Errorf("expected keyed codes matching (%t) '%c', but got %v", test.matches, chr, result.Keyed)
+		}
+		if result.Correct != test.expected {
+			t.Errorf("expected '%c' keyed with a jitter of %f (swapped: %t) scored %t, but got %+v", chr, test.jitter, test.swapped, test.expected, result)
+		}
+	}
+
+	if _, err := NewPracticeSession(nil, "~", opts, 0.3); err == nil {
+		t.Errorf("should fail with no encodable characters")
+	}
+	if _, err := NewPracticeSession(nil, "k", opts, -1); err == nil {
+		t.Errorf("should fail with a negative tolerance")
+	}
+}
+
+func TestPracticeSessionWithSpeeds(t *testing.T) {
+	played, restore := fakeSpeaker(func(sampleRate beep.SampleRate, bufferSize int) error { return nil })
+	defer restore()
+
+	// copied at 20 WPM, but sent at 10 WPM
+	opts := DefaultBeepOptions()
+	opts.WPM = 20
+	sendUnit := unitDuration(10)
+
+	session, err := NewPracticeSessionWithSpeeds(rand.New(rand.NewSource(42)), "KMRSU", opts, 10, 0.3)
+	if err != nil {
+		t.Fatalf("failed to create a session: %s", err)
+	}
+
+	// keys given `code` with a unit of `unit`
+	key := func(code Code, unit time.Duration) {
+		for i, chr := range code {
+			if i > 0 {
+				_ = session.Key(KeyEvent{Down: false, Duration: unit})
+			}
+
+			units := unitsDit
+			if chr == dahRune {
+				units = unitsDah
+			}
+			if err := session.Key(KeyEvent{Down: true, Duration: unit * time.Duration(units)}); err != nil {
+				t.Fatalf("failed to key: %s", err)
+			}
+		}
+	}
+
+	for _, test := range []struct {
+		unit     time.Duration
+		expected bool
+	}{
+		{sendUnit, true},      // at the send speed
+		{sendUnit / 2, false}, // at the copy speed
+	} {
+		*played = [][2]float64{}
+
+		chr, err := session.Next()
+		if err != nil {
+			t.Fatalf("failed to play: %s", err)
+		}
+		code, _ := charToCode(unicode.ToLower(chr))
+		if expected := Samples([]Code{code}, opts); !reflect.DeepEqual(*played, expected) {
+			t.Errorf("expected '%c' played at the copy speed, but played %d samples", chr, len(*played))
+		}
+
+		key(code, test.unit)
+		if result := session.Score(); result.Correct != test.expected {
+			t.Errorf("expected '%c' keyed with a unit of %s scored %t, but got %+v", chr, test.unit, test.expected, result)
+		}
+	}
+
+	if _, err := NewPracticeSessionWithSpeeds(nil, "k", opts, 0, 0.3); err == nil {
+		t.Errorf("should fail with a non-positive send WPM")
+	}
+}
+
+func TestRecommendWPM(t *testing.T) {
+	previous := 0.0
+	for _, level := range []Level{LevelBeginner, LevelIntermediate, LevelAdvanced, LevelExpert} {
+		wpm := RecommendWPM(level)
+		if wpm <= previous {
+			t.Errorf("recommended speed should increase with level: %f -> %f", previous, wpm)
+		}
+		previous = wpm
+	}
+
+	if wpm := RecommendWPM(Level(-1)); wpm != 0 {
+		t.Errorf("expected 0 for an unknown level, but got %f", wpm)
+	}
+}
+
+func TestRecommendedSessionLength(t *testing.T) {
+	previous := time.Duration(math.MaxInt64)
+	for _, wpm := range []float64{5, 10, 20, 30, 35} {
+		length := RecommendedSessionLength(wpm)
+		if length < 10*time.Minute || length > 30*time.Minute {
+			t.Errorf("recommended length at %.0f WPM is out of range: %s", wpm, length)
+		}
+		if length >= previous {
+			t.Errorf("recommended length should get shorter at %.0f WPM: %s -> %s", wpm, previous, length)
+		}
+		previous = length
+	}
+
+	// bounded
+	if length := RecommendedSessionLength(1); length != 30*time.Minute {
+		t.Errorf("expected 30 minutes for slow speeds, but got %s", length)
+	}
+	if length := RecommendedSessionLength(60); length != 10*time.Minute {
+		t.Errorf("expected 10 minutes for fast speeds, but got %s", length)
+	}
+	if length := RecommendedSessionLength(0); length != 0 {
+		t.Errorf("expected 0 for an invalid speed, but got %s", length)
+	}
+}
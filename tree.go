@@ -0,0 +1,46 @@
+package morse
+
+import (
+	"fmt"
+)
+
+// TreePath returns the path from the root of the decoding tree to given `code`,
+// where each step is false for going left (Dit) and true for going right (Dah).
+func TreePath(code Code) (path []bool, err error) {
+	if code == None || code == Space {
+		return nil, fmt.Errorf("no path for code: '%s'", code)
+	}
+
+	path = []bool{}
+	for _, chr := range code {
+		switch chr {
+		case ditRune:
+			path = append(path, false)
+		case dahRune:
+			path = append(path, true)
+		default:
+			return nil, fmt.Errorf("not a valid duration: '%c'", chr)
+		}
+	}
+
+	return path, nil
+}
+
+// CharAtPath returns the character at given `path` (see `TreePath`) of the decoding tree.
+func CharAtPath(path []bool) (chr rune, err error) {
+	durations := make([]Duration, len(path))
+	for i, dah := range path {
+		if dah {
+			durations[i] = Dah
+		} else {
+			durations[i] = Dit
+		}
+	}
+
+	code := CodeFromDurations(durations...)
+	if code == None {
+		return 0, fmt.Errorf("no character at the root")
+	}
+
+	return codeToChar(code)
+}
@@ -0,0 +1,47 @@
+package morse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTreePath(t *testing.T) {
+	// K: − • −
+	path, err := TreePath(K)
+	if err != nil {
+		t.Fatalf("failed to get path: %s", err)
+	}
+	if expected := []bool{true, false, true}; !reflect.DeepEqual(path, expected) {
+		t.Errorf("expected path %v, but got %v", expected, path)
+	}
+
+	if chr, err := CharAtPath(path); err != nil || chr != 'k' {
+		t.Errorf("expected 'k' at path %v, but got '%c' (%v)", path, chr, err)
+	}
+
+	// all characters should be found at their paths
+	for chr, code := range codesMap {
+		if code == Space {
+			continue
+		}
+
+		path, err := TreePath(code)
+		if err != nil {
+			t.Errorf("failed to get path of '%c': %s", chr, err)
+		} else if found, err := CharAtPath(path); err != nil || found != chr {
+			t.Errorf("expected '%c' at path %v, but got '%c' (%v)", chr, path, found, err)
+		}
+	}
+
+	// invalid codes and paths
+	for _, code := range []Code{None, Space, Code("abc")} {
+		if _, err := TreePath(code); err == nil {
+			t.Errorf("should fail to get path of '%s'", code)
+		}
+	}
+	for _, path := range [][]bool{{}, {false, false, false, false, false, false, false}} {
+		if _, err := CharAtPath(path); err == nil {
+			t.Errorf("should fail to get a character at path %v", path)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package morse
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// https://en.wikipedia.org/wiki/Wabun_code
+
+// combining marks of kana, split from voiced (and semi-voiced) kana with NFD
+const (
+	dakuten    = '\u3099' // combining voiced sound mark
+	handakuten = '\u309a' // combining semi-voiced sound mark
+)
+
+// map for Wabun codes and katakana
+//
+// It is kept apart from the codes map, as many of the codes collide with the ITU ones.
+var wabunMap map[rune]Code
+var wabunCharsMap map[Code]rune
+
+// small kana, encoded as their normal-sized ones
+var smallKana = map[rune]rune{
+	'ァ': 'ア', 'ィ': 'イ', 'ゥ': 'ウ', 'ェ': 'エ', 'ォ': 'オ',
+	'ッ': 'ツ', 'ャ': 'ヤ', 'ュ': 'ユ', 'ョ': 'ヨ', 'ヮ': 'ワ',
+	'ヵ': 'カ', 'ヶ': 'ケ',
+}
+
+// initialize Wabun maps
+func init() {
+	// in glyphs of '.' and '-', for readability
+	wabun := map[rune]string{
+		'イ': ".-", 'ロ': ".-.-", 'ハ': "-...", 'ニ': "-.-.", 'ホ': "-..",
+		'ヘ': ".", 'ト': "..-..", 'チ': "..-.", 'リ': "--.", 'ヌ': "....",
+		'ル': "-.--.", 'ヲ': ".---", 'ワ': "-.-", 'カ': ".-..", 'ヨ': "--",
+		'タ': "-.", 'レ': "---", 'ソ': "---.", 'ツ': ".--.", 'ネ': "--.-",
+		'ナ': ".-.", 'ラ': "...", 'ム': "-", 'ウ': "..-", 'ヰ': ".-..-",
+		'ノ': "..--", 'オ': ".-...", 'ク': "...-", 'ヤ': ".--", 'マ': "-..-",
+		'ケ': "-.--", 'フ': "--..", 'コ': "----", 'エ': "-.---", 'テ': ".-.--",
+		'ア': "--.--", 'サ': "-.-.-", 'キ': "-.-..", 'ユ': "-..--", 'メ': "-...-",
+		'ミ': "..-.-", 'シ': "--.-.", 'ヱ': ".--..", 'ヒ': "--..-", 'モ': "-..-.",
+		'セ': ".---.", 'ス': "---.-", 'ン': ".-.-.",
+
+		dakuten:    "..",
+		handakuten: "..--.",
+		'ー':        ".--.-", // long vowel mark
+		'、':        ".-.-.-",
+		'」':        ".-.-..",
+
+		' ': string(Space),
+	}
+
+	wabunMap = make(map[rune]Code)
+	wabunCharsMap = make(map[Code]rune)
+	for k, v := range wabun {
+		code := normalizeCode(Code(v))
+
+		wabunMap[k] = code
+		wabunCharsMap[code] = k
+	}
+}
+
+// normalizes given kana `text` to katakana for encoding in Wabun.
+//
+// Half-width katakana are widened (NFKC), hiragana are converted to katakana, small kana to normal-sized ones,
+// and voiced (or semi-voiced) kana are split into their bases and combining marks (NFD).
+func normalizeKana(text string) string {
+	text = norm.NFKC.String(text)
+
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'ぁ' && r <= 'ゖ': // hiragana
+			r += 'ァ' - 'ぁ'
+		case r == '゛':
+			return dakuten
+		case r == '゜':
+			return handakuten
+		}
+
+		if normal, exists := smallKana[r]; exists {
+			return normal
+		}
+		return r
+	}, text)
+
+	return norm.NFD.String(text)
+}
+
+// EncodeWabun encodes Wabun (Japanese morse) codes from given kana `text`.
+//
+// Both katakana and hiragana are accepted, and voiced (or semi-voiced) kana
+// are encoded as their bases followed by the codes of dakuten (or handakuten).
+//
+// Will return an error when given `text` includes non-encodable characters.
+func EncodeWabun(text string) (codes []Code, err error) {
+	codes = []Code{}
+
+	for _, chr := range normalizeKana(text) {
+		code, exists := wabunMap[chr]
+		if !exists {
+			return []Code{}, fmt.Errorf("'%s' is not encodable: no matching character in the Wabun map: '%c'", text, chr)
+		}
+
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// DecodeWabun decodes given Wabun (Japanese morse) `codes` to a string of katakana.
+//
+// Codes of dakuten (or handakuten) are composed with their preceding kana when possible (eg. 'タ' and dakuten to 'ダ').
+func DecodeWabun(codes []Code) (decoded string, err error) {
+	chars := []rune{}
+
+	for _, code := range codes {
+		chr, exists := wabunCharsMap[code]
+		if !exists {
+			return "", fmt.Errorf("'%v' are not decodable: no matching code in the Wabun map: '%s'", codes, code)
+		}
+
+		chars = append(chars, chr)
+	}
+
+	return norm.NFC.String(string(chars)), nil
+}
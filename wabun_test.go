@@ -0,0 +1,56 @@
+package morse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWabun(t *testing.T) {
+	// codes should not collide
+	if len(wabunCharsMap) != len(wabunMap) {
+		t.Errorf("expected %d codes in the Wabun chars map, but got %d", len(wabunMap), len(wabunCharsMap))
+	}
+
+	// round trips
+	for _, text := range []string{"カ", "ナ", "ダ", "パン", "カナ ダ", "コーヒー"} {
+		codes, err := EncodeWabun(text)
+		if err != nil {
+			t.Errorf("failed to encode '%s' in Wabun: %s", text, err)
+			continue
+		}
+
+		if decoded, err := DecodeWabun(codes); err != nil {
+			t.Errorf("failed to decode '%s' in Wabun: %s", text, err)
+		} else if decoded != text {
+			t.Errorf("expected '%s', but got '%s'", text, decoded)
+		}
+	}
+
+	// voiced kana as their bases followed by dakuten
+	if codes, err := EncodeWabun("ダ"); err != nil {
+		t.Errorf("failed to encode in Wabun: %s", err)
+	} else if expected := []Code{N, I}; !reflect.DeepEqual(codes, expected) {
+		t.Errorf("expected %v, but got %v", expected, codes)
+	}
+
+	// hiragana, small kana, and half-width katakana are encoded as katakana
+	for text, expected := range map[string]string{
+		"かな":  "カナ",
+		"ぱっく": "パツク",
+		"ｶﾞ":  "ガ",
+	} {
+		if codes, err := EncodeWabun(text); err != nil {
+			t.Errorf("failed to encode '%s' in Wabun: %s", text, err)
+		} else if decoded, _ := DecodeWabun(codes); decoded != expected {
+			t.Errorf("expected '%s', but got '%s'", expected, decoded)
+		}
+	}
+
+	// errors
+	if _, err := EncodeWabun("カa"); err == nil {
+		t.Errorf("should fail with a non-kana character")
+	}
+	if _, err := DecodeWabun([]Code{Code(Dit + Dit + Dit + Dit + Dit + Dit + Dit)}); err == nil {
+		t.Errorf("should fail with an unknown code")
+	}
+}
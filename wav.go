@@ -0,0 +1,381 @@
+package morse
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+)
+
+// constants for WAV files
+const (
+	wavHeaderSize      = 44 // of PCM
+	wavFloatHeaderSize = 58 // of IEEE float, with the extended fmt chunk and a fact chunk
+	wavChannels        = 1
+
+	wavFormatPCM       = 1
+	wavFormatIEEEFloat = 3
+)
+
+// BitDepth for samples of WAV files
+type BitDepth int
+
+// Bit depths of WAV files
+const (
+	BitDepth8       BitDepth = 8  // 8-bit unsigned PCM
+	BitDepth16      BitDepth = 16 // 16-bit signed PCM
+	BitDepth24      BitDepth = 24 // 24-bit signed PCM
+	BitDepth32Float BitDepth = 32 // 32-bit IEEE float
+)
+
+// WAVWriter writes sounds of morse codes to a stream of mono WAV.
+//
+// Codes can be written in chunks with `WriteCodes`, and `Close` must be called
+// at the end for finalizing the header.
+type WAVWriter struct {
+	w    io.WriteSeeker
+	opts BeepOptions
+
+	samples int64         // number of samples written so far
+	elapsed time.Duration // duration of the sounds written so far
+	tail    []Code        // codes written last, for scheduling gaps before the next chunk
+	pending []Code        // codes held back, which may begin the callsign
+	closed  bool
+}
+
+// NewWAVWriter creates a new `WAVWriter` which writes to `w` with the same tones, timing, and bit depth
+// as `WriteWAV` with `opts`.
+//
+// A header is written immediately, and its sizes are filled in when the writer is closed.
+// As the end of the message is not known while streaming, `MessageFadeIn` and `MessageFadeOut` are not applied,
+// and `PreambleDits` are written only before the first chunk.
+func NewWAVWriter(w io.WriteSeeker, opts BeepOptions) (writer *WAVWriter, err error) {
+	if err = opts.validate(); err != nil {
+		return nil, err
+	}
+
+	writer = &WAVWriter{
+		w:    w,
+		opts: opts,
+	}
+
+	if err = writer.writeHeader(); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+// WriteCodes appends sounds of given `codes` to the stream.
+//
+// Gaps between chunks are scheduled with the codes written before, in the same way as writing all at once
+// (eg. gaps between words when either side is a `Space`, tighter ones in the callsign, or extra ones between
+// a letter and a digit). Codes which may begin the callsign at the end of a chunk are held back
+// until the following codes (or `Close`) tell whether it is there.
+func (w *WAVWriter) WriteCodes(codes []Code) (err error) {
+	if w.closed {
+		return fmt.Errorf("writer is already closed")
+	}
+
+	return w.write(codes, false)
+}
+
+// writes given `codes` after the pending ones, holding back the ones which may begin the callsign unless `flush` is set
+func (w *WAVWriter) write(codes []Code, flush bool) (err error) {
+	// options of the whole message are not applied to chunks
+	opts := w.opts
+	opts.MessageFadeIn, opts.MessageFadeOut = 0, 0
+	if w.samples > 0 {
+		opts.PreambleDits = 0
+	}
+
+	// rendered after the codes written last, which are dropped from the samples
+	context := append(append(slices.Clone(w.tail), w.pending...), codes...)
+
+	var ends []time.Duration
+	if _, ends, err = opts.spans(context); err != nil {
+		return fmt.Errorf("failed to write '%v': %s", codes, err)
+	}
+
+	held := 0
+	if !flush {
+		held = w.heldBack(context[len(w.tail):])
+	}
+	ready := len(context) - held
+
+	// ends of the last tones written before, and to be written now
+	tailEnd, readyEnd := lastEnd(context[:len(w.tail)], ends), lastEnd(context[:ready], ends)
+	if readyEnd > tailEnd {
+		offset := w.elapsed - tailEnd
+
+		var samples [][2]float64
+		if samples, err = renderCodesAt(context, opts, offset); err != nil {
+			return fmt.Errorf("failed to write '%v': %s", codes, err)
+		}
+		sr := w.opts.sampleRate()
+		from, to := w.opts.samples(sr, w.elapsed)-w.opts.samples(sr, offset), w.opts.samples(sr, offset+readyEnd)-w.opts.samples(sr, offset)
+		if err = w.writeSamples(samples[from:min(to, len(samples))]); err != nil {
+			return err
+		}
+
+		w.elapsed = offset + readyEnd
+	}
+	w.tail, w.pending = trimTail(context[:ready]), slices.Clone(context[ready:])
+
+	return nil
+}
+
+// returns the number of codes at the end of given `codes` which may begin the callsign of the options
+func (w *WAVWriter) heldBack(codes []Code) int {
+	callsign, err := w.opts.callsignCodes()
+	if w.opts.Callsign == "" || err != nil {
+		return 0
+	}
+
+	for n := min(len(callsign)-1, len(codes)); n > 0; n-- {
+		if slices.Equal(codes[len(codes)-n:], callsign[:n]) {
+			return n
+		}
+	}
+
+	return 0
+}
+
+// returns the end of the last tone of given `codes` in `ends`, or 0 if there is none
+func lastEnd(codes []Code, ends []time.Duration) time.Duration {
+	for i := len(codes) - 1; i >= 0; i-- {
+		if codes[i] != Space {
+			return ends[i]
+		}
+	}
+
+	return 0
+}
+
+// returns the end of given `codes` which is needed for scheduling the next chunk:
+// the last non-`Space` code, and `Space`s after it.
+func trimTail(codes []Code) []Code {
+	for i := len(codes) - 1; i >= 0; i-- {
+		if codes[i] != Space {
+			return slices.Clone(codes[i:])
+		}
+	}
+
+	return slices.Clone(codes)
+}
+
+// Close finalizes the header of the stream.
+//
+// It does not close the underlying writer.
+func (w *WAVWriter) Close() (err error) {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	// write the codes held back
+	if err = w.write(nil, true); err != nil {
+		return err
+	}
+
+	// pad the data chunk to an even size, as required for chunks of RIFF
+	dataSize := uint32(w.samples * int64(w.blockAlign()))
+	if dataSize%2 != 0 {
+		if _, err = w.w.Write([]byte{0}); err != nil {
+			return fmt.Errorf("failed to write a pad byte: %s", err)
+		}
+	}
+
+	// rewrite the header with the sizes
+	if _, err = w.w.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to the header: %s", err)
+	}
+	if err = writeWAVHeader(w.w, int(w.opts.sampleRate()), w.opts.bitDepth(), dataSize); err != nil {
+		return err
+	}
+
+	if _, err = w.w.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("failed to seek to the end: %s", err)
+	}
+
+	return nil
+}
+
+// bytes per sample frame
+func (w *WAVWriter) blockAlign() int {
+	return blockAlign(w.opts.bitDepth())
+}
+
+// writes a header with empty sizes
+func (w *WAVWriter) writeHeader() error {
+	return writeWAVHeader(w.w, int(w.opts.sampleRate()), w.opts.bitDepth(), 0)
+}
+
+// writes given rendered samples
+//
+// Will return an error without writing when the data chunk would be too large for a WAV file.
+func (w *WAVWriter) writeSamples(samples [][2]float64) error {
+	if err := checkWAVDataSize((w.samples+int64(len(samples)))*int64(w.blockAlign()), w.opts.bitDepth()); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 0, len(samples)*w.blockAlign())
+	for _, sample := range samples {
+		buf = w.appendSample(buf, sample[0])
+	}
+
+	if _, err := w.w.Write(buf); err != nil {
+		return fmt.Errorf("failed to write samples: %s", err)
+	}
+	w.samples += int64(len(samples))
+
+	return nil
+}
+
+// appends given sample (-1.0 ~ 1.0) to `buf` in the bit depth of the writer
+func (w *WAVWriter) appendSample(buf []byte, sample float64) []byte {
+	return appendSample(buf, sample, w.opts.bitDepth())
+}
+
+// WriteWAV writes sounds of given `codes` with `opts` to `w` as a mono WAV in the bit depth of `opts`,
+// with the same tones and timing as the ones played with `BeepWith`.
+//
+// As the whole sounds are rendered before writing, `w` does not need to be seekable (eg. `bytes.Buffer`).
+// Will return an error when `codes` or `opts` are not valid, or the sounds are too long for a WAV file.
+func WriteWAV(w io.Writer, codes []Code, opts BeepOptions) (err error) {
+	var samples [][2]float64
+	if samples, err = renderCodes(codes, opts); err != nil {
+		return fmt.Errorf("failed to write '%v': %s", codes, err)
+	}
+
+	bitDepth := opts.bitDepth()
+	if err = checkWAVDataSize(int64(len(samples)*blockAlign(bitDepth)), bitDepth); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 0, len(samples)*blockAlign(bitDepth))
+	for _, sample := range samples {
+		buf = appendSample(buf, sample[0], bitDepth)
+	}
+
+	if err = writeWAVHeader(w, int(opts.sampleRate()), bitDepth, uint32(len(buf))); err != nil {
+		return err
+	}
+
+	// pad the data chunk to an even size, as required for chunks of RIFF
+	if len(buf)%2 != 0 {
+		buf = append(buf, 0)
+	}
+	if _, err = w.Write(buf); err != nil {
+		return fmt.Errorf("failed to write samples: %s", err)
+	}
+
+	return nil
+}
+
+// checks if given bit depth is supported
+func validateBitDepth(bitDepth BitDepth) error {
+	switch bitDepth {
+	case BitDepth8, BitDepth16, BitDepth24, BitDepth32Float:
+		return nil
+	default:
+		return fmt.Errorf("not a supported bit depth: %d", bitDepth)
+	}
+}
+
+// bytes per sample frame of given bit depth
+func blockAlign(bitDepth BitDepth) int {
+	return wavChannels * int(bitDepth) / 8
+}
+
+// checks if a data chunk of given `size` (in bytes) fits in a WAV file of given bit depth,
+// with the sizes of the chunks (including the pad byte) in 32 bits
+func checkWAVDataSize(size int64, bitDepth BitDepth) error {
+	if limit := int64(math.MaxUint32) - int64(wavHeaderSizeOf(bitDepth)-8) - 1; size > limit {
+		return fmt.Errorf("data of %d bytes is too large for a WAV file (max: %d bytes)", size, limit)
+	}
+	return nil
+}
+
+// size of the header of given bit depth
+func wavHeaderSizeOf(bitDepth BitDepth) int {
+	if bitDepth == BitDepth32Float {
+		return wavFloatHeaderSize
+	}
+	return wavHeaderSize
+}
+
+// writes a header with given size of the data chunk
+//
+// The RIFF chunk size includes a pad byte after the data chunk of an odd size, which should be written by the caller.
+//
+// Non-PCM (IEEE float) ones have the extended fmt chunk (with the size of extension) and a fact chunk
+// (with the number of sample frames), as required for formats other than PCM.
+func writeWAVHeader(w io.Writer, sampleRate int, bitDepth BitDepth, dataSize uint32) error {
+	blockAlign := blockAlign(bitDepth)
+
+	header := []any{
+		[4]byte{'R', 'I', 'F', 'F'},
+		uint32(wavHeaderSizeOf(bitDepth)-8) + dataSize + dataSize%2, // RIFF chunk size, with the pad byte
+		[4]byte{'W', 'A', 'V', 'E'},
+	}
+
+	if bitDepth == BitDepth32Float {
+		header = append(header,
+			[4]byte{'f', 'm', 't', ' '},
+			uint32(18),                    // fmt chunk size
+			uint16(wavFormatIEEEFloat),    // format: IEEE float
+			uint16(wavChannels),           // number of channels
+			uint32(sampleRate),            // sample rate
+			uint32(sampleRate*blockAlign), // byte rate
+			uint16(blockAlign),            // block align
+			uint16(bitDepth),              // bits per sample
+			uint16(0),                     // size of extension
+
+			[4]byte{'f', 'a', 'c', 't'},
+			uint32(4),                   // fact chunk size
+			dataSize/uint32(blockAlign), // number of sample frames
+		)
+	} else {
+		header = append(header,
+			[4]byte{'f', 'm', 't', ' '},
+			uint32(16),                    // fmt chunk size
+			uint16(wavFormatPCM),          // format: PCM
+			uint16(wavChannels),           // number of channels
+			uint32(sampleRate),            // sample rate
+			uint32(sampleRate*blockAlign), // byte rate
+			uint16(blockAlign),            // block align
+			uint16(bitDepth),              // bits per sample
+		)
+	}
+
+	header = append(header,
+		[4]byte{'d', 'a', 't', 'a'},
+		dataSize, // data chunk size
+	)
+
+	for _, field := range header {
+		if err := binary.Write(w, binary.LittleEndian, field); err != nil {
+			return fmt.Errorf("failed to write WAV header: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// appends given sample (-1.0 ~ 1.0) to `buf` in given bit depth
+func appendSample(buf []byte, sample float64, bitDepth BitDepth) []byte {
+	switch bitDepth {
+	case BitDepth8:
+		return append(buf, uint8(128+int(sample*math.MaxInt8)))
+	case BitDepth24:
+		v := int32(sample * (1<<23 - 1))
+		return append(buf, byte(v), byte(v>>8), byte(v>>16))
+	case BitDepth32Float:
+		return binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(sample)))
+	default:
+		return binary.LittleEndian.AppendUint16(buf, uint16(int16(sample*math.MaxInt16)))
+	}
+}
@@ -0,0 +1,438 @@
+package morse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// in-memory io.WriteSeeker for testing
+type memWriteSeeker struct {
+	buf []byte
+	pos int64
+}
+
+func (m *memWriteSeeker) Write(p []byte) (n int, err error) {
+	if end := m.pos + int64(len(p)); end > int64(len(m.buf)) {
+		m.buf = append(m.buf, make([]byte, end-int64(len(m.buf)))...)
+	}
+	n = copy(m.buf[m.pos:], p)
+	m.pos += int64(n)
+	return n, nil
+}
+
+func (m *memWriteSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		m.pos = offset
+	case io.SeekCurrent:
+		m.pos += offset
+	case io.SeekEnd:
+		m.pos = int64(len(m.buf)) + offset
+	}
+	if m.pos < 0 {
+		return 0, errors.New("negative position")
+	}
+	return m.pos, nil
+}
+
+func TestWAVWriter(t *testing.T) {
+	const sampleRate = 8000
+
+	opts := DefaultBeepOptions()
+	opts.SampleRate = sampleRate
+
+	out := &memWriteSeeker{}
+
+	writer, err := NewWAVWriter(out, opts)
+	if err != nil {
+		t.Fatalf("failed to create WAV writer: %s", err)
+	}
+
+	// write in chunks
+	for _, chunk := range [][]Code{
+		{S, O},
+		{S},
+		{},
+		{Space, E},
+	} {
+		if err := writer.WriteCodes(chunk); err != nil {
+			t.Fatalf("failed to write codes: %s", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close WAV writer: %s", err)
+	}
+	if err := writer.WriteCodes([]Code{E}); err == nil {
+		t.Errorf("should fail to write after closed")
+	}
+
+	// the same as writing all at once
+	expectedDataSize := uint32(len(Samples([]Code{S, O, S, Space, E}, opts)) * 2)
+
+	if len(out.buf) != wavHeaderSize+int(expectedDataSize) {
+		t.Errorf("expected %d bytes, but got %d", wavHeaderSize+int(expectedDataSize), len(out.buf))
+	}
+	if string(out.buf[0:4]) != "RIFF" || string(out.buf[8:12]) != "WAVE" || string(out.buf[36:40]) != "data" {
+		t.Errorf("invalid WAV header: %q", out.buf[:wavHeaderSize])
+	}
+	if riffSize := binary.LittleEndian.Uint32(out.buf[4:8]); riffSize != 36+expectedDataSize {
+		t.Errorf("expected RIFF chunk size %d, but got %d", 36+expectedDataSize, riffSize)
+	}
+	if dataSize := binary.LittleEndian.Uint32(out.buf[40:44]); dataSize != expectedDataSize {
+		t.Errorf("expected data chunk size %d, but got %d", expectedDataSize, dataSize)
+	}
+	if rate := binary.LittleEndian.Uint32(out.buf[24:28]); rate != sampleRate {
+		t.Errorf("expected sample rate %d, but got %d", sampleRate, rate)
+	}
+
+	// invalid codes
+	writer, _ = NewWAVWriter(&memWriteSeeker{}, opts)
+	if err := writer.WriteCodes([]Code{Code("abc")}); err == nil {
+		t.Errorf("should fail to write invalid codes")
+	}
+
+	// too large for a WAV file
+	writer, _ = NewWAVWriter(&memWriteSeeker{}, opts)
+	writer.samples = math.MaxUint32/2 - 100
+	if err := writer.WriteCodes([]Code{S}); err == nil {
+		t.Errorf("should fail to write data larger than 4 GiB")
+	}
+}
+
+func TestWAVWriterWithOptions(t *testing.T) {
+	opts := DefaultBeepOptions()
+	opts.SampleRate = 8000
+	opts.WPM = 18
+	opts.CharWPM, opts.EffectiveWPM = 18, 12
+	opts.BitDepth = BitDepth24
+	opts.LetterDigitGap, opts.Callsign, opts.PreambleDits = 2, "hl1abc", 2
+
+	// gaps at boundaries of chunks are scheduled with the codes on both sides
+	for _, chunks := range [][]string{
+		{"so", "s ", "e"},
+		{"a", "1"},
+		{"de hl1", "abc k"},
+		{"de hl", "1ab", "c k"},
+		{"hl1", "hl1abc"},
+		{"cq hl1"},
+		{"de ", " ", "k"},
+		{" ", "k", ""},
+	} {
+		out := &memWriteSeeker{}
+		writer, err := NewWAVWriter(out, opts)
+		if err != nil {
+			t.Fatalf("failed to create WAV writer: %s", err)
+		}
+		for _, chunk := range chunks {
+			codes := EncodeLenient(chunk)
+			if err := writer.WriteCodes(codes); err != nil {
+				t.Fatalf("failed to write codes: %s", err)
+			}
+		}
+		writer.Close()
+
+		// the same as writing all at once
+		codes, _ := Encode(strings.Join(chunks, ""))
+		var buf bytes.Buffer
+		if err := WriteWAV(&buf, codes, opts); err != nil {
+			t.Fatalf("failed to write WAV: %s", err)
+		}
+		if !bytes.Equal(out.buf, buf.Bytes()) {
+			t.Errorf("expected %d bytes same as writing %q all at once, but got %d", buf.Len(), chunks, len(out.buf))
+		}
+	}
+
+	// invalid options
+	if _, err := NewWAVWriter(&memWriteSeeker{}, BeepOptions{}); err == nil {
+		t.Errorf("should fail to create WAV writer with invalid options")
+	}
+	opts.BitDepth = 12
+	if _, err := NewWAVWriter(&memWriteSeeker{}, opts); err == nil {
+		t.Errorf("should fail to create WAV writer with an unsupported bit depth")
+	}
+}
+
+// checks sizes of the chunks in given WAV of `bitDepth`,
+// and the extended fmt chunk and the fact chunk of a non-PCM one
+func checkWAVChunks(t *testing.T, out []byte, bitDepth BitDepth, expectedDataSize uint32) {
+	t.Helper()
+
+	headerSize := wavHeaderSize
+	if bitDepth == BitDepth32Float {
+		headerSize = wavFloatHeaderSize
+
+		if fmtSize := binary.LittleEndian.Uint32(out[16:20]); fmtSize != 18 {
+			t.Errorf("expected fmt chunk size 18 for float, but got %d", fmtSize)
+		}
+		if extension := binary.LittleEndian.Uint16(out[36:38]); extension != 0 {
+			t.Errorf("expected no extension in fmt chunk, but got a size of %d", extension)
+		}
+		if id := string(out[38:42]); id != "fact" {
+			t.Errorf("expected a fact chunk, but got '%s'", id)
+		}
+		if factSize := binary.LittleEndian.Uint32(out[42:46]); factSize != 4 {
+			t.Errorf("expected fact chunk size 4, but got %d", factSize)
+		}
+		if frames := binary.LittleEndian.Uint32(out[46:50]); frames != expectedDataSize/4 {
+			t.Errorf("expected %d sample frames in fact chunk, but got %d", expectedDataSize/4, frames)
+		}
+	} else if fmtSize := binary.LittleEndian.Uint32(out[16:20]); fmtSize != 16 {
+		t.Errorf("expected fmt chunk size 16 for PCM, but got %d", fmtSize)
+	}
+
+	if id := string(out[headerSize-8 : headerSize-4]); id != "data" {
+		t.Errorf("expected a data chunk, but got '%s'", id)
+	}
+	if riffSize := binary.LittleEndian.Uint32(out[4:8]); int(riffSize) != len(out)-8 {
+		t.Errorf("expected RIFF chunk size %d for bit depth %d, but got %d", len(out)-8, bitDepth, riffSize)
+	}
+	if dataSize := binary.LittleEndian.Uint32(out[headerSize-4 : headerSize]); dataSize != expectedDataSize || len(out) != headerSize+int(dataSize+dataSize%2) {
+		t.Errorf("expected data chunk size %d for bit depth %d, but got %d (%d bytes)", expectedDataSize, bitDepth, dataSize, len(out))
+	}
+}
+
+func TestWAVWriterBitDepths(t *testing.T) {
+	const sampleRate = 8000
+
+	for _, test := range []struct {
+		bitDepth   BitDepth
+		format     uint16
+		blockAlign uint16
+	}{
+		{BitDepth8, 1, 1},
+		{BitDepth16, 1, 2},
+		{BitDepth24, 1, 3},
+		{BitDepth32Float, 3, 4},
+	} {
+		opts := DefaultBeepOptions()
+		opts.SampleRate = sampleRate
+		opts.BitDepth = test.bitDepth
+
+		out := &memWriteSeeker{}
+
+		writer, err := NewWAVWriter(out, opts)
+		if err != nil {
+			t.Fatalf("failed to create WAV writer: %s", err)
+		}
+		if err := writer.WriteCodes([]Code{A}); err != nil {
+			t.Fatalf("failed to write codes: %s", err)
+		}
+		writer.Close()
+
+		expectedDataSize := uint32(len(Samples([]Code{A}, opts))) * uint32(test.blockAlign)
+
+		if format := binary.LittleEndian.Uint16(out.buf[20:22]); format != test.format {
+			t.Errorf("expected format %d for bit depth %d, but got %d", test.format, test.bitDepth, format)
+		}
+		if byteRate := binary.LittleEndian.Uint32(out.buf[28:32]); byteRate != sampleRate*uint32(test.blockAlign) {
+			t.Errorf("expected byte rate %d for bit depth %d, but got %d", sampleRate*uint32(test.blockAlign), test.bitDepth, byteRate)
+		}
+		if blockAlign := binary.LittleEndian.Uint16(out.buf[32:34]); blockAlign != test.blockAlign {
+			t.Errorf("expected block align %d for bit depth %d, but got %d", test.blockAlign, test.bitDepth, blockAlign)
+		}
+		if bitsPerSample := binary.LittleEndian.Uint16(out.buf[34:36]); bitsPerSample != uint16(test.bitDepth) {
+			t.Errorf("expected %d bits per sample, but got %d", test.bitDepth, bitsPerSample)
+		}
+		checkWAVChunks(t, out.buf, test.bitDepth, expectedDataSize)
+	}
+}
+
+func TestWriteWAV(t *testing.T) {
+	codes, _ := Encode("sos")
+
+	opts := DefaultBeepOptions()
+	opts.SampleRate = 8000
+	opts.WPM = 20
+	opts.Volume = 0.5
+
+	var buf bytes.Buffer
+	if err := WriteWAV(&buf, codes, opts); err != nil {
+		t.Fatalf("failed to write WAV: %s", err)
+	}
+	out := buf.Bytes()
+
+	// samples of each tone and silence
+	expectedSamples := 0
+	for _, signal := range Timeline(codes, opts.WPM) {
+		expectedSamples += beep.SampleRate(opts.SampleRate).N(signal.Duration)
+	}
+	expectedDataSize := uint32(expectedSamples * 2)
+
+	if len(out) != wavHeaderSize+int(expectedDataSize) {
+		t.Fatalf("expected %d bytes, but got %d", wavHeaderSize+int(expectedDataSize), len(out))
+	}
+	if string(out[0:4]) != "RIFF" || string(out[8:12]) != "WAVE" || string(out[12:16]) != "fmt " || string(out[36:40]) != "data" {
+		t.Errorf("invalid WAV header: %q", out[:wavHeaderSize])
+	}
+	for _, field := range []struct {
+		name     string
+		value    uint32
+		expected uint32
+	}{
+		{"RIFF chunk size", binary.LittleEndian.Uint32(out[4:8]), 36 + expectedDataSize},
+		{"format", uint32(binary.LittleEndian.Uint16(out[20:22])), wavFormatPCM},
+		{"channels", uint32(binary.LittleEndian.Uint16(out[22:24])), 1},
+		{"sample rate", binary.LittleEndian.Uint32(out[24:28]), 8000},
+		{"byte rate", binary.LittleEndian.Uint32(out[28:32]), 16000},
+		{"block align", uint32(binary.LittleEndian.Uint16(out[32:34])), 2},
+		{"bits per sample", uint32(binary.LittleEndian.Uint16(out[34:36])), 16},
+		{"data chunk size", binary.LittleEndian.Uint32(out[40:44]), expectedDataSize},
+	} {
+		if field.value != field.expected {
+			t.Errorf("expected %s %d, but got %d", field.name, field.expected, field.value)
+		}
+	}
+
+	// volume
+	peak := 0
+	for i := wavHeaderSize; i < len(out); i += 2 {
+		v := int(int16(binary.LittleEndian.Uint16(out[i:])))
+		peak = max(peak, v, -v)
+	}
+	if peak > math.MaxInt16/2 || peak < math.MaxInt16*45/100 {
+		t.Errorf("expected peak amplitude of a half, but got %d", peak)
+	}
+
+	// invalid codes and options
+	if err := WriteWAV(&bytes.Buffer{}, []Code{Code("abc")}, opts); err == nil {
+		t.Errorf("should fail to write invalid codes")
+	}
+	if err := WriteWAV(&bytes.Buffer{}, codes, BeepOptions{}); err == nil {
+		t.Errorf("should fail to write with invalid options")
+	}
+}
+
+func TestWriteWAVBitDepths(t *testing.T) {
+	codes, _ := Encode("e")
+
+	opts := DefaultBeepOptions()
+	opts.SampleRate = 8000
+
+	samples := len(Samples(codes, opts))
+	for _, test := range []struct {
+		bitDepth   BitDepth
+		format     uint16
+		blockAlign int
+	}{
+		{BitDepth8, wavFormatPCM, 1},
+		{BitDepth16, wavFormatPCM, 2},
+		{BitDepth24, wavFormatPCM, 3},
+		{BitDepth32Float, wavFormatIEEEFloat, 4},
+	} {
+		opts.BitDepth = test.bitDepth
+
+		var buf bytes.Buffer
+		if err := WriteWAV(&buf, codes, opts); err != nil {
+			t.Fatalf("failed to write WAV with bit depth %d: %s", test.bitDepth, err)
+		}
+		out := buf.Bytes()
+
+		if format := binary.LittleEndian.Uint16(out[20:22]); format != test.format {
+			t.Errorf("expected format %d for bit depth %d, but got %d", test.format, test.bitDepth, format)
+		}
+		if bitsPerSample := binary.LittleEndian.Uint16(out[34:36]); bitsPerSample != uint16(test.bitDepth) {
+			t.Errorf("expected %d bits per sample, but got %d", test.bitDepth, bitsPerSample)
+		}
+		checkWAVChunks(t, out, test.bitDepth, uint32(samples*test.blockAlign))
+	}
+
+	opts.BitDepth = 12
+	if err := WriteWAV(&bytes.Buffer{}, codes, opts); err == nil {
+		t.Errorf("should fail to write WAV with an unsupported bit depth")
+	}
+}
+
+func TestWAVOddDataSize(t *testing.T) {
+	codes := []Code{E}
+
+	opts := DefaultBeepOptions()
+	opts.SampleRate = 8010
+
+	samples := len(Samples(codes, opts))
+	if samples%2 == 0 {
+		t.Fatalf("expected an odd number of samples, but got %d", samples)
+	}
+
+	for _, bitDepth := range []BitDepth{BitDepth8, BitDepth24} {
+		opts.BitDepth = bitDepth
+		expectedDataSize := uint32(samples * blockAlign(bitDepth))
+
+		// written all at once
+		var buf bytes.Buffer
+		if err := WriteWAV(&buf, codes, opts); err != nil {
+			t.Fatalf("failed to write WAV with bit depth %d: %s", bitDepth, err)
+		}
+		checkWAVChunks(t, buf.Bytes(), bitDepth, expectedDataSize)
+		if size := buf.Len(); size%2 != 0 || buf.Bytes()[size-1] != 0 {
+			t.Errorf("expected a pad byte at the end for bit depth %d, but got %d bytes", bitDepth, size)
+		}
+
+		// written in chunks
+		out := &memWriteSeeker{}
+		writer, err := NewWAVWriter(out, opts)
+		if err != nil {
+			t.Fatalf("failed to create WAV writer: %s", err)
+		}
+		writer.WriteCodes(codes)
+		writer.Close()
+		writer.Close() // no more pad bytes
+
+		checkWAVChunks(t, out.buf, bitDepth, expectedDataSize)
+		if !bytes.Equal(out.buf, buf.Bytes()) {
+			t.Errorf("expected %d bytes same as writing all at once for bit depth %d, but got %d", buf.Len(), bitDepth, len(out.buf))
+		}
+	}
+}
+
+func TestWAVWriterRamp(t *testing.T) {
+	opts := DefaultBeepOptions()
+	opts.SampleRate = 8000
+	opts.BitDepth = BitDepth32Float
+
+	// float samples written with given ramp
+	write := func(ramp time.Duration) (samples []float32) {
+		opts.Ramp = ramp
+
+		out := &memWriteSeeker{}
+		writer, err := NewWAVWriter(out, opts)
+		if err != nil {
+			t.Fatalf("failed to create WAV writer: %s", err)
+		}
+		writer.WriteCodes([]Code{E})
+		writer.Close()
+
+		for i := wavFloatHeaderSize; i+4 <= len(out.buf); i += 4 {
+			samples = append(samples, math.Float32frombits(binary.LittleEndian.Uint32(out.buf[i:])))
+		}
+		return samples
+	}
+	ramped, abrupt := write(defaultRamp), write(0)
+
+	// attack and release below full amplitude, ramping monotonically
+	ramp := beep.SampleRate(opts.SampleRate).N(defaultRamp)
+	last := 0.0
+	for i := 0; i < ramp; i++ {
+		if math.Abs(float64(abrupt[i])) < 0.1 {
+			continue
+		}
+
+		gain := float64(ramped[i] / abrupt[i])
+		if gain >= 1 || gain < last {
+			t.Errorf("attack should ramp up monotonically below full amplitude: %f -> %f", last, gain)
+		}
+		last = gain
+	}
+	for _, i := range []int{len(ramped) - 1, len(ramped) - 2} {
+		if math.Abs(float64(ramped[i])) > math.Abs(float64(abrupt[i])) || math.Abs(float64(ramped[i])) > 0.1 {
+			t.Errorf("release should end near silence, but got %f at sample %d", ramped[i], i)
+		}
+	}
+}